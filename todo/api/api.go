@@ -0,0 +1,10 @@
+// Package api holds the OpenAPI document of the todoServer REST API,
+// the contract the server and its clients keep to
+package api
+
+import _ "embed"
+
+// Spec is the OpenAPI 3 document of the API, in JSON
+//
+//go:embed openapi.json
+var Spec []byte
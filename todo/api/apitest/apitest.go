@@ -0,0 +1,449 @@
+// Package apitest checks requests and responses against the OpenAPI
+// document of the todoServer REST API, so the tests of the server and
+// its clients keep them to the same contract. It's only meant for tests
+package apitest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"todo/api"
+)
+
+// ErrContract is wrapped by the errors of requests and
+// responses not following the document
+var ErrContract = errors.New("API contract violation")
+
+// Document is the subset of an OpenAPI document used to check
+// requests and responses. Path items can't have parameters of
+// their own, only their operations
+type Document struct {
+	Paths      map[string]map[string]*Operation `json:"paths"`
+	Components struct {
+		Schemas    map[string]*Schema    `json:"schemas"`
+		Parameters map[string]*Parameter `json:"parameters"`
+		Responses  map[string]*Response  `json:"responses"`
+	} `json:"components"`
+}
+
+// Operation is a method on a path
+type Operation struct {
+	OperationID string       `json:"operationId"`
+	Parameters  []*Parameter `json:"parameters"`
+	RequestBody *struct {
+		Required bool                  `json:"required"`
+		Content  map[string]*MediaType `json:"content"`
+	} `json:"requestBody"`
+	Responses map[string]*Response `json:"responses"`
+}
+
+// Parameter is a path or query parameter of an operation
+type Parameter struct {
+	Ref             string  `json:"$ref"`
+	Name            string  `json:"name"`
+	In              string  `json:"in"`
+	Required        bool    `json:"required"`
+	AllowEmptyValue bool    `json:"allowEmptyValue"`
+	Schema          *Schema `json:"schema"`
+}
+
+// Response is a response of an operation, without
+// content when it has no body
+type Response struct {
+	Ref     string `json:"$ref"`
+	Headers map[string]*struct {
+		Required bool `json:"required"`
+	} `json:"headers"`
+	Content map[string]*MediaType `json:"content"`
+}
+
+// MediaType gives the schema of a body
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Schema is the subset of the JSON schemas used by the document
+type Schema struct {
+	Ref                  string             `json:"$ref"`
+	Type                 string             `json:"type"`
+	Format               string             `json:"format"`
+	Nullable             bool               `json:"nullable"`
+	Enum                 []interface{}      `json:"enum"`
+	Minimum              *float64           `json:"minimum"`
+	Maximum              *float64           `json:"maximum"`
+	Properties           map[string]*Schema `json:"properties"`
+	Required             []string           `json:"required"`
+	AdditionalProperties *bool              `json:"additionalProperties"`
+	Items                *Schema            `json:"items"`
+}
+
+// Load parses the document in api.Spec
+func Load() (*Document, error) {
+	d := &Document{}
+	if err := json.Unmarshal(api.Spec, d); err != nil {
+		return nil, fmt.Errorf("invalid OpenAPI document: %w", err)
+	}
+	return d, nil
+}
+
+// Operations returns the IDs of all the operations, sorted
+func (d *Document) Operations() []string {
+	var ids []string
+	for _, item := range d.Paths {
+		for _, op := range item {
+			ids = append(ids, op.OperationID)
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// Find returns the operation for the method on the path, such as
+// /todo/1, with the values of the path parameters by name
+func (d *Document) Find(method, path string) (*Operation, map[string]string, error) {
+	segments := strings.Split(path, "/")
+	for tmpl, item := range d.Paths {
+		params, ok := matchPath(strings.Split(tmpl, "/"), segments)
+		if !ok {
+			continue
+		}
+		op, ok := item[strings.ToLower(method)]
+		if !ok {
+			return nil, nil, fmt.Errorf("%w: %s %s: method not in the document", ErrContract, method, path)
+		}
+		return op, params, nil
+	}
+	return nil, nil, fmt.Errorf("%w: %s %s: path not in the document", ErrContract, method, path)
+}
+
+// matchPath matches the segments of a path template
+// with the ones of a path
+func matchPath(tmpl, segments []string) (map[string]string, bool) {
+	if len(tmpl) != len(segments) {
+		return nil, false
+	}
+	params := map[string]string{}
+	for i, s := range tmpl {
+		if strings.HasPrefix(s, "{") && strings.HasSuffix(s, "}") {
+			if segments[i] == "" {
+				return nil, false
+			}
+			params[s[1:len(s)-1]] = segments[i]
+			continue
+		}
+		if s != segments[i] {
+			return nil, false
+		}
+	}
+	return params, true
+}
+
+// ValidateRequest checks the parameters and body of a request.
+// The body is read and replaced, so the request can still be sent
+func (d *Document) ValidateRequest(r *http.Request) error {
+	op, pathParams, err := d.Find(r.Method, r.URL.Path)
+	if err != nil {
+		return err
+	}
+	where := fmt.Sprintf("%s %s", r.Method, r.URL.Path)
+
+	query := r.URL.Query()
+	known := map[string]bool{}
+	for _, p := range op.Parameters {
+		p, err := d.parameter(p)
+		if err != nil {
+			return err
+		}
+
+		var (
+			value   string
+			present bool
+		)
+		switch p.In {
+		case "path":
+			value, present = pathParams[p.Name]
+		case "query":
+			known[p.Name] = true
+			_, present = query[p.Name]
+			value = query.Get(p.Name)
+		default:
+			continue
+		}
+		if !present {
+			if p.Required {
+				return fmt.Errorf("%w: %s: missing %s param %q", ErrContract, where, p.In, p.Name)
+			}
+			continue
+		}
+		if value == "" && p.AllowEmptyValue {
+			continue
+		}
+		if err := d.validateParam(p, value); err != nil {
+			return fmt.Errorf("%w: %s: %s param %q: %s", ErrContract, where, p.In, p.Name, err)
+		}
+	}
+	for name := range query {
+		if !known[name] {
+			return fmt.Errorf("%w: %s: unknown query param %q", ErrContract, where, name)
+		}
+	}
+
+	var body []byte
+	if r.Body != nil {
+		body, err = io.ReadAll(r.Body)
+		if err != nil {
+			return err
+		}
+		r.Body.Close()
+		r.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	if op.RequestBody == nil {
+		if len(bytes.TrimSpace(body)) > 0 {
+			return fmt.Errorf("%w: %s: unexpected body", ErrContract, where)
+		}
+		return nil
+	}
+	if len(bytes.TrimSpace(body)) == 0 {
+		if op.RequestBody.Required {
+			return fmt.Errorf("%w: %s: missing body", ErrContract, where)
+		}
+		return nil
+	}
+	if err := d.validateBody(op.RequestBody.Content, r.Header.Get("Content-Type"), body); err != nil {
+		return fmt.Errorf("%w: %s: request %s", ErrContract, where, err)
+	}
+	return nil
+}
+
+// ValidateResponse checks the status, headers and body of the response
+// to a request for the method on the path
+func (d *Document) ValidateResponse(method, path string, status int,
+	header http.Header, body []byte) error {
+	op, _, err := d.Find(method, path)
+	if err != nil {
+		return err
+	}
+	where := fmt.Sprintf("%s %s: %d", method, path, status)
+
+	resp, ok := op.Responses[strconv.Itoa(status)]
+	if !ok {
+		resp, ok = op.Responses["default"]
+	}
+	if !ok {
+		return fmt.Errorf("%w: %s: status not in the document", ErrContract, where)
+	}
+	if resp, err = d.response(resp); err != nil {
+		return err
+	}
+
+	for name, h := range resp.Headers {
+		if h.Required && header.Get(name) == "" {
+			return fmt.Errorf("%w: %s: missing header %q", ErrContract, where, name)
+		}
+	}
+
+	if len(resp.Content) == 0 {
+		if len(bytes.TrimSpace(body)) > 0 {
+			return fmt.Errorf("%w: %s: unexpected body", ErrContract, where)
+		}
+		return nil
+	}
+	if err := d.validateBody(resp.Content, header.Get("Content-Type"), body); err != nil {
+		return fmt.Errorf("%w: %s: response %s", ErrContract, where, err)
+	}
+	return nil
+}
+
+// validateBody checks a body has one of the content types
+// and matches its schema when it's JSON
+func (d *Document) validateBody(content map[string]*MediaType, contentType string, body []byte) error {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return fmt.Errorf("has an invalid Content-Type %q", contentType)
+	}
+	mt, ok := content[mediaType]
+	if !ok {
+		return fmt.Errorf("has an unexpected Content-Type %q", contentType)
+	}
+	if mediaType != "application/json" || mt.Schema == nil {
+		return nil
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return fmt.Errorf("body is invalid JSON: %s", err)
+	}
+	if err := d.Validate(mt.Schema, v); err != nil {
+		return fmt.Errorf("body: %s", err)
+	}
+	return nil
+}
+
+// validateParam checks a parameter value, given as text
+func (d *Document) validateParam(p *Parameter, value string) error {
+	s, err := d.schema(p.Schema)
+	if err != nil {
+		return err
+	}
+
+	var v interface{} = value
+	switch s.Type {
+	case "integer", "number":
+		v = json.Number(value)
+	case "boolean":
+		b, err := strconv.ParseBool(value)
+		if err != nil || (value != "true" && value != "false") {
+			return fmt.Errorf("%q is not a boolean", value)
+		}
+		v = b
+	}
+	return d.Validate(s, v)
+}
+
+// Validate checks a value decoded from JSON, with numbers
+// as json.Number, matches the schema
+func (d *Document) Validate(s *Schema, v interface{}) error {
+	return d.validate(s, v, "$")
+}
+
+func (d *Document) validate(s *Schema, v interface{}, at string) error {
+	s, err := d.schema(s)
+	if err != nil {
+		return err
+	}
+
+	if v == nil {
+		if s.Nullable || s.Type == "" {
+			return nil
+		}
+		return fmt.Errorf("%s: null is not allowed", at)
+	}
+
+	switch s.Type {
+	case "object":
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%s: expected an object", at)
+		}
+		for _, name := range s.Required {
+			if _, ok := obj[name]; !ok {
+				return fmt.Errorf("%s: missing property %q", at, name)
+			}
+		}
+		for name, pv := range obj {
+			ps, ok := s.Properties[name]
+			if !ok {
+				if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+					return fmt.Errorf("%s: unknown property %q", at, name)
+				}
+				continue
+			}
+			if err := d.validate(ps, pv, at+"."+name); err != nil {
+				return err
+			}
+		}
+	case "array":
+		arr, ok := v.([]interface{})
+		if !ok {
+			return fmt.Errorf("%s: expected an array", at)
+		}
+		if s.Items != nil {
+			for i, iv := range arr {
+				if err := d.validate(s.Items, iv, fmt.Sprintf("%s[%d]", at, i)); err != nil {
+					return err
+				}
+			}
+		}
+	case "string":
+		str, ok := v.(string)
+		if !ok {
+			return fmt.Errorf("%s: expected a string", at)
+		}
+		if s.Format == "date-time" {
+			if _, err := time.Parse(time.RFC3339Nano, str); err != nil {
+				return fmt.Errorf("%s: %q is not a date-time", at, str)
+			}
+		}
+	case "integer", "number":
+		n, ok := v.(json.Number)
+		if !ok {
+			return fmt.Errorf("%s: expected a number", at)
+		}
+		f, err := n.Float64()
+		if err != nil {
+			return fmt.Errorf("%s: %q is not a number", at, n)
+		}
+		if s.Type == "integer" {
+			if _, err := n.Int64(); err != nil {
+				return fmt.Errorf("%s: %q is not an integer", at, n)
+			}
+		}
+		if s.Minimum != nil && f < *s.Minimum {
+			return fmt.Errorf("%s: %s is less than %v", at, n, *s.Minimum)
+		}
+		if s.Maximum != nil && f > *s.Maximum {
+			return fmt.Errorf("%s: %s is more than %v", at, n, *s.Maximum)
+		}
+	case "boolean":
+		if _, ok := v.(bool); !ok {
+			return fmt.Errorf("%s: expected a boolean", at)
+		}
+	}
+
+	if len(s.Enum) > 0 {
+		for _, e := range s.Enum {
+			if fmt.Sprint(e) == fmt.Sprint(v) {
+				return nil
+			}
+		}
+		return fmt.Errorf("%s: %v is not one of %v", at, v, s.Enum)
+	}
+	return nil
+}
+
+// schema resolves a schema reference
+func (d *Document) schema(s *Schema) (*Schema, error) {
+	if s == nil || s.Ref == "" {
+		return s, nil
+	}
+	name := strings.TrimPrefix(s.Ref, "#/components/schemas/")
+	if rs, ok := d.Components.Schemas[name]; ok {
+		return rs, nil
+	}
+	return nil, fmt.Errorf("unknown schema %q", s.Ref)
+}
+
+// parameter resolves a parameter reference
+func (d *Document) parameter(p *Parameter) (*Parameter, error) {
+	if p.Ref == "" {
+		return p, nil
+	}
+	name := strings.TrimPrefix(p.Ref, "#/components/parameters/")
+	if rp, ok := d.Components.Parameters[name]; ok {
+		return rp, nil
+	}
+	return nil, fmt.Errorf("unknown parameter %q", p.Ref)
+}
+
+// response resolves a response reference
+func (d *Document) response(r *Response) (*Response, error) {
+	if r.Ref == "" {
+		return r, nil
+	}
+	name := strings.TrimPrefix(r.Ref, "#/components/responses/")
+	if rr, ok := d.Components.Responses[name]; ok {
+		return rr, nil
+	}
+	return nil, fmt.Errorf("unknown response %q", r.Ref)
+}
@@ -0,0 +1,171 @@
+package apitest_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"todo/api"
+	"todo/api/apitest"
+)
+
+func TestLoad(t *testing.T) {
+	d, err := apitest.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// every reference resolves
+	refs := map[string]bool{}
+	var walk func(v interface{})
+	walk = func(v interface{}) {
+		switch v := v.(type) {
+		case map[string]interface{}:
+			if ref, ok := v["$ref"].(string); ok {
+				refs[ref] = true
+			}
+			for _, e := range v {
+				walk(e)
+			}
+		case []interface{}:
+			for _, e := range v {
+				walk(e)
+			}
+		}
+	}
+	var raw interface{}
+	if err := json.Unmarshal(api.Spec, &raw); err != nil {
+		t.Fatal(err)
+	}
+	walk(raw)
+	for ref := range refs {
+		parts := strings.Split(ref, "/")
+		if len(parts) != 4 || parts[0] != "#" || parts[1] != "components" {
+			t.Errorf("Unexpected reference %q", ref)
+			continue
+		}
+		var found bool
+		switch parts[2] {
+		case "schemas":
+			_, found = d.Components.Schemas[parts[3]]
+		case "parameters":
+			_, found = d.Components.Parameters[parts[3]]
+		case "responses":
+			_, found = d.Components.Responses[parts[3]]
+		}
+		if !found {
+			t.Errorf("Unresolved reference %q", ref)
+		}
+	}
+
+	if len(d.Operations()) != 15 {
+		t.Errorf("Expected 15 operations, got %v", d.Operations())
+	}
+}
+
+func TestValidateRequest(t *testing.T) {
+	d, err := apitest.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name   string
+		method string
+		url    string
+		body   string
+		expErr string
+	}{
+		{"GetAll", http.MethodGet, "/todo?done=false&limit=10&offset=20&sort=-due", "", ""},
+		{"Add", http.MethodPost, "/lists/work/todo", `{"task":"Write report","blockedBy":[1]}`, ""},
+		{"Complete", http.MethodPatch, "/todo/1?complete", "", ""},
+		{"Edit", http.MethodPatch, "/todo/1", `{"task":"Buy milk"}`, ""},
+		{"UnknownPath", http.MethodGet, "/items", "", "path not in the document"},
+		{"UnknownMethod", http.MethodPost, "/lists", "", "method not in the document"},
+		{"InvalidID", http.MethodGet, "/todo/abc", "", `path param "id"`},
+		{"LimitTooLarge", http.MethodGet, "/todo?limit=5000", "", `query param "limit"`},
+		{"InvalidDone", http.MethodGet, "/todo?done=yes", "", `query param "done"`},
+		{"UnknownParam", http.MethodGet, "/todo?page=2", "", `unknown query param "page"`},
+		{"MissingBody", http.MethodPost, "/todo", "", "missing body"},
+		{"MissingTask", http.MethodPost, "/todo", `{"parent":1}`, `missing property "task"`},
+		{"CapitalizedField", http.MethodPost, "/todo", `{"Task":"Buy milk"}`, `missing property "task"`},
+		{"UnknownField", http.MethodPatch, "/todo/1", `{"task":"a","priority":"A"}`, `unknown property "priority"`},
+		{"WrongType", http.MethodPatch, "/todo/1", `{"position":"top"}`, "$.position: expected a number"},
+		{"UnexpectedBody", http.MethodDelete, "/todo/1", `{"task":"a"}`, "unexpected body"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r, err := http.NewRequest(tc.method, "http://localhost:8080"+tc.url, strings.NewReader(tc.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			r.Header.Set("Content-Type", "application/json")
+
+			err = d.ValidateRequest(r)
+			if tc.expErr == "" {
+				if err != nil {
+					t.Fatalf("Expected no error, got %q", err)
+				}
+				return
+			}
+			if !errors.Is(err, apitest.ErrContract) || !strings.Contains(err.Error(), tc.expErr) {
+				t.Errorf("Expected error %q, got %v", tc.expErr, err)
+			}
+		})
+	}
+}
+
+func TestValidateResponse(t *testing.T) {
+	d, err := apitest.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	item := `{"ID":1,"Task":"Buy milk","Done":false,"CreateAt":"2026-10-18T10:00:00Z",
+"CompletedAt":"0001-01-01T00:00:00Z","Priority":"","Due":"0001-01-01T00:00:00Z","Tags":null,
+"Notes":"","Recur":"","Parent":0,"BlockedBy":null,"ListName":""}`
+	jsonHeader := http.Header{"Content-Type": {"application/json"}}
+
+	testCases := []struct {
+		name   string
+		path   string
+		status int
+		header http.Header
+		body   string
+		expErr string
+	}{
+		{"Items", "/todo", http.StatusOK, jsonHeader,
+			`{"results":[` + item + `],"date":1,"totalResults":1,"offset":0}`, ""},
+		{"Error", "/todo/3", http.StatusNotFound,
+			http.Header{"Content-Type": {"application/json"}, "X-Request-Id": {"abc"}},
+			`{"error":{"code":"not_found","message":"Not Found","requestId":"abc"}}`, ""},
+		{"MissingRequestID", "/todo/3", http.StatusNotFound, jsonHeader,
+			`{"error":{"code":"not_found","message":"Not Found","requestId":"abc"}}`, `missing header "X-Request-ID"`},
+		{"TextError", "/todo/3", http.StatusNotFound,
+			http.Header{"Content-Type": {"text/plain"}, "X-Request-Id": {"abc"}},
+			"Not Found", "unexpected Content-Type"},
+		{"RenamedField", "/todo", http.StatusOK, jsonHeader,
+			`{"results":[` + strings.Replace(item, "CompletedAt", "Completed", 1) + `],"date":1,"totalResults":1,"offset":0}`,
+			`missing property "CompletedAt"`},
+		{"InvalidTime", "/todo", http.StatusOK, jsonHeader,
+			`{"results":[` + strings.Replace(item, "2026-10-18T10:00:00Z", "yesterday", 1) + `],"date":1,"totalResults":1,"offset":0}`,
+			"$.results[0].CreateAt"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := d.ValidateResponse(http.MethodGet, tc.path, tc.status, tc.header, []byte(tc.body))
+			if tc.expErr == "" {
+				if err != nil {
+					t.Fatalf("Expected no error, got %q", err)
+				}
+				return
+			}
+			if !errors.Is(err, apitest.ErrContract) || !strings.Contains(err.Error(), tc.expErr) {
+				t.Errorf("Expected error %q, got %v", tc.expErr, err)
+			}
+		})
+	}
+}
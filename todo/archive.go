@@ -0,0 +1,105 @@
+package todo
+
+import (
+	"fmt"
+	"time"
+)
+
+// Archive removes from the list the items completed before cutoff
+// and returns them, in list order. Their subtasks move up to their
+// parent and the items they blocked aren't blocked by them anymore
+func (list *List) Archive(cutoff time.Time) List {
+	old := List{}
+	for _, v := range list.Items {
+		if v.Done && v.CompletedAt.Before(cutoff) {
+			old.Items = append(old.Items, v)
+		}
+	}
+	for _, v := range old.Items {
+		list.Delete(v.ID)
+	}
+	return old
+}
+
+// Purge deletes the items completed before cutoff, like Archive
+// without keeping them. It returns the number of items deleted
+func (list *List) Purge(cutoff time.Time) int {
+	return len(list.Archive(cutoff).Items)
+}
+
+// ArchiveTo moves the items completed before cutoff from the list kept
+// in store to the end of the list kept in archive, holding the lock of
+// store. The items are added to the archive before they're removed from
+// store, and taken out of the archive again when store can't be saved.
+// It returns the number of items moved
+func ArchiveTo(store, archive Storage, cutoff time.Time) (int, error) {
+	unlock, err := store.Lock()
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	list := &List{}
+	if err := store.Get(list); err != nil {
+		return 0, err
+	}
+	old := list.Archive(cutoff)
+	if len(old.Items) == 0 {
+		return 0, nil
+	}
+
+	if err := AppendArchive(archive, old); err != nil {
+		return 0, err
+	}
+	if err := store.Save(list); err != nil {
+		return 0, RemoveArchived(archive, old, err)
+	}
+	return len(old.Items), nil
+}
+
+// AppendArchive adds the archived items to the end
+// of the list kept in archive
+func AppendArchive(archive Storage, old List) error {
+	return Update(archive, func(a *List) error {
+		a.Items = append(a.Items, old.Items...)
+		return nil
+	})
+}
+
+// RemoveArchived takes the archived items out of the list kept in
+// archive again, after cause kept them from being removed from their
+// own list. It returns cause, along with the error removing them if any
+func RemoveArchived(archive Storage, old List, cause error) error {
+	ids := map[int]bool{}
+	for _, v := range old.Items {
+		ids[v.ID] = true
+	}
+	err := Update(archive, func(a *List) error {
+		kept := a.Items[:0]
+		for _, v := range a.Items {
+			if !ids[v.ID] {
+				kept = append(kept, v)
+			}
+		}
+		a.Items = kept
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("%w (the items are also left in the archive: %v)", cause, err)
+	}
+	return cause
+}
+
+// PurgeFrom deletes the items completed before cutoff from the
+// list kept in store. It returns the number of items deleted
+func PurgeFrom(store Storage, cutoff time.Time) (int, error) {
+	n := 0
+	err := Update(store, func(list *List) error {
+		n = list.Purge(cutoff)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return n, nil
+}
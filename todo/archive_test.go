@@ -0,0 +1,195 @@
+package todo_test
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+	"todo"
+)
+
+// completedList returns a list with items completed the given
+// number of days ago, or open for negative numbers
+func completedList(t *testing.T, days ...int) todo.List {
+	t.Helper()
+
+	ls := todo.List{}
+	now := time.Now()
+	for _, d := range days {
+		id := ls.Add("Task")
+		if d < 0 {
+			continue
+		}
+		if err := ls.Complete(id); err != nil {
+			t.Fatal(err)
+		}
+		ls.Items[len(ls.Items)-1].CompletedAt = now.AddDate(0, 0, -d)
+	}
+	return ls
+}
+
+func TestList_Archive(t *testing.T) {
+	ls := completedList(t, 40, -1, 10, 31)
+	ls.SetParent(2, 1)
+
+	old := ls.Archive(time.Now().AddDate(0, 0, -30))
+	if len(old.Items) != 2 || old.Items[0].ID != 1 || old.Items[1].ID != 4 {
+		t.Fatalf("Expected items 1 and 4 archived, got:\n%s", old.String())
+	}
+	if len(ls.Items) != 2 || ls.Items[0].ID != 2 || ls.Items[1].ID != 3 {
+		t.Fatalf("Expected items 2 and 3 left, got:\n%s", ls.String())
+	}
+	if ls.Items[0].Parent != 0 {
+		t.Errorf("Expected subtask of archived item to be top-level, got parent %d.", ls.Items[0].Parent)
+	}
+
+	if n := ls.Purge(time.Now()); n != 1 {
+		t.Errorf("Expected 1 item purged, got %d instead.", n)
+	}
+	if len(ls.Items) != 1 || ls.Items[0].Done {
+		t.Errorf("Expected only the open item left, got:\n%s", ls.String())
+	}
+}
+
+func TestArchiveTo(t *testing.T) {
+	store, err := todo.NewStorage(todo.StorageMemory, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	archive, err := todo.NewStorage(todo.StorageMemory, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ls := completedList(t, 5, -1, 3)
+	if err := store.Save(&ls); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, exp := range []int{2, 0} {
+		n, err := todo.ArchiveTo(store, archive, time.Now().AddDate(0, 0, -1))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n != exp {
+			t.Errorf("Expected %d items archived, got %d instead.", exp, n)
+		}
+	}
+
+	got := todo.List{}
+	if err := archive.Get(&got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Items) != 2 || got.Items[0].ID != 1 || got.Items[1].ID != 3 {
+		t.Errorf("Expected items 1 and 3 in the archive, got:\n%s", got.String())
+	}
+
+	n, err := todo.PurgeFrom(store, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 0 {
+		t.Errorf("Expected no items purged, got %d instead.", n)
+	}
+	if err := store.Get(&got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Items) != 1 || got.Items[0].ID != 2 {
+		t.Errorf("Expected item 2 left, got:\n%s", got.String())
+	}
+}
+
+func TestArchiveTo_Twice(t *testing.T) {
+	for _, kind := range []string{todo.StorageJSON, todo.StorageSQLite} {
+		t.Run(kind, func(t *testing.T) {
+			dir := t.TempDir()
+			store, err := todo.NewStorage(kind, filepath.Join(dir, "todo"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer store.Close()
+			archive, err := todo.NewStorage(kind, filepath.Join(dir, "archive"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer archive.Close()
+
+			// the last item is archived each time, so
+			// a reused ID would replace or repeat it
+			for _, task := range []string{"Task 1", "Task 2", "Task 3"} {
+				err := todo.Update(store, func(list *todo.List) error {
+					return list.Complete(list.Add(task))
+				})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if _, err := todo.ArchiveTo(store, archive, time.Now().Add(time.Minute)); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			got := todo.List{}
+			if err := archive.Get(&got); err != nil {
+				t.Fatal(err)
+			}
+			if exp := "X 1: Task 1\nX 2: Task 2\nX 3: Task 3\n"; got.String() != exp {
+				t.Errorf("Expected archive:\n%s\ngot:\n%s", exp, got.String())
+			}
+		})
+	}
+}
+
+// failingStorage fails to save the list
+type failingStorage struct {
+	todo.Storage
+}
+
+var errSave = errors.New("disk full")
+
+func (s failingStorage) Save(list *todo.List) error {
+	return errSave
+}
+
+func TestArchiveTo_SaveFails(t *testing.T) {
+	store, err := todo.NewStorage(todo.StorageMemory, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	archive, err := todo.NewStorage(todo.StorageMemory, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the archive already has an item
+	a := completedList(t, 10)
+	if err := archive.Save(&a); err != nil {
+		t.Fatal(err)
+	}
+	ls := completedList(t, 10, 5, -1)
+	ls.Items = ls.Items[1:]
+	if err := store.Save(&ls); err != nil {
+		t.Fatal(err)
+	}
+
+	n, err := todo.ArchiveTo(failingStorage{store}, archive, time.Now())
+	if !errors.Is(err, errSave) {
+		t.Fatalf("Expected %q, got %v instead.", errSave, err)
+	}
+	if n != 0 {
+		t.Errorf("Expected no items archived, got %d instead.", n)
+	}
+
+	got := todo.List{}
+	if err := archive.Get(&got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Items) != 1 || got.Items[0].ID != 1 {
+		t.Errorf("Expected only item 1 in the archive, got:\n%s", got.String())
+	}
+	if err := store.Get(&got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Items) != 2 {
+		t.Errorf("Expected the list unchanged, got:\n%s", got.String())
+	}
+}
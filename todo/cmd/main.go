@@ -21,7 +21,7 @@ func main() {
 	switch {
 	// for no extra arguments, print the list
 	case len(os.Args) == 1:
-		for _, item := range *ls {
+		for _, item := range ls.Items {
 			fmt.Println(item.Task)
 		}
 	// concatenate all provided arguments with a space and
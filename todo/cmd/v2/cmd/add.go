@@ -0,0 +1,116 @@
+/*
+Copyright © 2023 youngzy
+Copyrights apply to this source code.
+Check LICENSE for details.
+
+*/
+package cmd
+
+import (
+	"io"
+	"os"
+	"todo"
+
+	"github.com/spf13/cobra"
+)
+
+// details of the tasks to add
+type addOptions struct {
+	bulk     bool
+	priority string
+	due      string
+	tags     string
+	recur    string
+	parent   int
+}
+
+// addCmd represents the add command
+var addCmd = &cobra.Command{
+	Use:   "add [task]",
+	Short: "Add a task to the list",
+	Long: `Add a task to the list, from the arguments or from STDIN.
+
+From STDIN, the first line is the task and the lines after
+a blank line are its notes. With --bulk, every non-blank
+line of STDIN is added as a task.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var opts addOptions
+		var err error
+		flags := cmd.Flags()
+		if opts.bulk, err = flags.GetBool("bulk"); err != nil {
+			return err
+		}
+		if opts.priority, err = flags.GetString("priority"); err != nil {
+			return err
+		}
+		if opts.due, err = flags.GetString("due"); err != nil {
+			return err
+		}
+		if opts.tags, err = flags.GetString("tags"); err != nil {
+			return err
+		}
+		if opts.recur, err = flags.GetString("recur"); err != nil {
+			return err
+		}
+		if opts.parent, err = flags.GetInt("parent"); err != nil {
+			return err
+		}
+		return addAction(os.Stdin, args, opts)
+	},
+}
+
+func addAction(in io.Reader, args []string, opts addOptions) error {
+	var (
+		tasks []string
+		notes string
+		err   error
+	)
+	if opts.bulk {
+		tasks, err = getTasks(in, args...)
+	} else {
+		var t string
+		t, notes, err = getTask(in, args...)
+		tasks = []string{t}
+	}
+	if err != nil {
+		return err
+	}
+
+	return updateList("add", func(ls *todo.List) error {
+		for _, t := range tasks {
+			id := ls.Add(t)
+
+			// set the optional details
+			if err := setDetails(ls, id, opts.priority, opts.due, opts.tags, opts.recur); err != nil {
+				return err
+			}
+			if err := ls.SetNotes(id, notes); err != nil {
+				return err
+			}
+			if err := ls.MoveTo(id, listName); err != nil {
+				return err
+			}
+			if err := ls.SetParent(id, opts.parent); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func init() {
+	rootCmd.AddCommand(addCmd)
+
+	addCmd.Flags().Bool("bulk", false, "Add every non-blank line of STDIN as a task")
+	addCmd.Flags().StringP("priority", "p", "", "Priority (A-Z) of the task")
+	addCmd.Flags().StringP("due", "d", "",
+		"Due date (YYYY-MM-DD) of the task, with an optional time as YYYY-MM-DDTHH:MM")
+	addCmd.Flags().StringP("tags", "t", "", "Comma-separated tags of the task")
+	addCmd.Flags().String("recur", "",
+		"Recurrence of the task: daily, weekdays, weekly[:mon,thu] or monthly[:15]")
+	addCmd.Flags().Int("parent", 0, "ID of the item the task is a subtask of")
+
+	addCmd.RegisterFlagCompletionFunc("parent", completeFlagItemIDs(allItems))
+	addCmd.RegisterFlagCompletionFunc("recur", cobra.FixedCompletions(
+		[]string{"daily", "weekdays", "weekly", "monthly"}, cobra.ShellCompDirectiveNoFileComp))
+}
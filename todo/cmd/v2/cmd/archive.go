@@ -0,0 +1,144 @@
+/*
+Copyright © 2023 youngzy
+Copyrights apply to this source code.
+Check LICENSE for details.
+
+*/
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+	"todo"
+
+	"github.com/spf13/cobra"
+)
+
+// archiveCmd represents the archive command
+var archiveCmd = &cobra.Command{
+	Use:   "archive",
+	Short: "Move the completed tasks, in all lists, to the archive",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		days, err := cmd.Flags().GetInt("days")
+		if err != nil {
+			return err
+		}
+		return archiveAction(os.Stdout, days)
+	},
+}
+
+// purgeCmd represents the purge command
+var purgeCmd = &cobra.Command{
+	Use:   "purge",
+	Short: "Delete the completed tasks, in all lists",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		days, err := cmd.Flags().GetInt("days")
+		if err != nil {
+			return err
+		}
+		return purgeAction(os.Stdout, days)
+	},
+}
+
+// archivedCmd represents the archived command
+var archivedCmd = &cobra.Command{
+	Use:   "archived",
+	Short: "List the archived tasks",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		format, err := cmd.Flags().GetString("format")
+		if err != nil {
+			return err
+		}
+		return archivedAction(os.Stdout, format)
+	},
+}
+
+// the archive is kept next to the list with the same storage
+// and key, once the list is opened
+func openArchive() (todo.Storage, error) {
+	return todo.NewStorage(todoStorage, todoFileName+".archive", todo.WithKey(listKey))
+}
+
+func archiveAction(out io.Writer, days int) error {
+	s, err := openSession(false)
+	if err != nil {
+		return err
+	}
+	defer s.close()
+
+	arch, err := openArchive()
+	if err != nil {
+		return err
+	}
+	defer arch.Close()
+
+	// the items are only removed from the list once they're
+	// archived, and taken out of the archive again when the
+	// list can't be saved
+	old := s.list.Archive(time.Now().AddDate(0, 0, -days))
+	if len(old.Items) > 0 {
+		if err := todo.AppendArchive(arch, old); err != nil {
+			return err
+		}
+		if err := s.store.Save(s.list); err != nil {
+			return todo.RemoveArchived(arch, old, err)
+		}
+		if err := s.record(todo.OpArchive); err != nil {
+			return err
+		}
+	}
+
+	_, err = fmt.Fprintf(out, "Archived %d items\n", len(old.Items))
+	return err
+}
+
+func purgeAction(out io.Writer, days int) error {
+	var n int
+	err := updateList("purge", func(ls *todo.List) error {
+		n = ls.Purge(time.Now().AddDate(0, 0, -days))
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(out, "Purged %d items\n", n)
+	return err
+}
+
+func archivedAction(out io.Writer, format string) error {
+	formatter, err := todo.NewFormatter(format)
+	if err != nil {
+		return err
+	}
+
+	// the list is loaded for its passphrase and lock
+	return readList(func(*todo.List) error {
+		arch, err := openArchive()
+		if err != nil {
+			return err
+		}
+		defer arch.Close()
+
+		a := &todo.List{}
+		if err := arch.Get(a); err != nil {
+			return err
+		}
+		return formatter.Format(out, a.Named(listName))
+	})
+}
+
+func init() {
+	rootCmd.AddCommand(archiveCmd)
+	rootCmd.AddCommand(purgeCmd)
+	rootCmd.AddCommand(archivedCmd)
+
+	archiveCmd.Flags().Int("days", 0, "Only the tasks completed more than the given days ago")
+	purgeCmd.Flags().Int("days", 0, "Only the tasks completed more than the given days ago")
+	addFormatFlag(archivedCmd)
+}
@@ -0,0 +1,74 @@
+/*
+Copyright © 2023 youngzy
+Copyrights apply to this source code.
+Check LICENSE for details.
+
+*/
+package cmd
+
+import (
+	"todo"
+
+	"github.com/spf13/cobra"
+)
+
+// blockCmd represents the block command
+var blockCmd = &cobra.Command{
+	Use:               "block <id> --by <ids>",
+	Short:             "Block a task until other tasks are completed",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeItemIDs(openItems),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		by, err := cmd.Flags().GetString("by")
+		if err != nil {
+			return err
+		}
+		return blockAction("block", args[0], by)
+	},
+}
+
+// unblockCmd represents the unblock command
+var unblockCmd = &cobra.Command{
+	Use:               "unblock <id> --by <ids>",
+	Short:             "Stop blocking a task by other tasks",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeItemIDs(openItems),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		by, err := cmd.Flags().GetString("by")
+		if err != nil {
+			return err
+		}
+		return blockAction("unblock", args[0], by)
+	},
+}
+
+// change the items blocking the item given by arg, as
+// the block or unblock op
+func blockAction(op string, arg string, by string) error {
+	id, err := parseID(arg)
+	if err != nil {
+		return err
+	}
+	ids, err := parseIDs(by)
+	if err != nil {
+		return err
+	}
+
+	return updateList(op, func(ls *todo.List) error {
+		if op == "unblock" {
+			return ls.Unblock(id, ids...)
+		}
+		return ls.Block(id, ids...)
+	})
+}
+
+func init() {
+	rootCmd.AddCommand(blockCmd)
+	rootCmd.AddCommand(unblockCmd)
+
+	for _, cmd := range []*cobra.Command{blockCmd, unblockCmd} {
+		cmd.Flags().String("by", "", "Comma-separated IDs of the blocking tasks")
+		cmd.MarkFlagRequired("by")
+		cmd.RegisterFlagCompletionFunc("by", completeFlagItemIDs(allItems))
+	}
+}
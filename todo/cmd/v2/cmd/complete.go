@@ -0,0 +1,38 @@
+/*
+Copyright © 2023 youngzy
+Copyrights apply to this source code.
+Check LICENSE for details.
+
+*/
+package cmd
+
+import (
+	"todo"
+
+	"github.com/spf13/cobra"
+)
+
+// completeCmd represents the complete command
+var completeCmd = &cobra.Command{
+	Use:               "complete <id>",
+	Short:             "Mark a task as completed",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeItemIDs(openItems),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return completeAction(args[0])
+	},
+}
+
+func completeAction(arg string) error {
+	id, err := parseID(arg)
+	if err != nil {
+		return err
+	}
+	return updateList("complete", func(ls *todo.List) error {
+		return ls.Complete(id)
+	})
+}
+
+func init() {
+	rootCmd.AddCommand(completeCmd)
+}
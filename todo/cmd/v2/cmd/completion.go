@@ -0,0 +1,157 @@
+/*
+Copyright © 2023 youngzy
+Copyrights apply to this source code.
+Check LICENSE for details.
+
+*/
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"todo"
+
+	"github.com/spf13/cobra"
+)
+
+// completionCmd represents the completion command
+var completionCmd = &cobra.Command{
+	Use:   "completion <bash|zsh|fish>",
+	Short: "Generate the completion script for your shell",
+	Long: `Generate the completion script for bash, zsh or fish. Item IDs
+are completed with their task.
+
+To load the completion in the current bash shell run:
+source <(todo completion bash)
+
+To load it on login, add that line to your ~/.bashrc file, or
+for zsh to your ~/.zshrc file with:
+source <(todo completion zsh)
+
+For fish, run once:
+todo completion fish > ~/.config/fish/completions/todo.fish`,
+	Args:      cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
+	ValidArgs: []string{"bash", "zsh", "fish"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return completionAction(os.Stdout, args[0])
+	},
+}
+
+func completionAction(out io.Writer, shell string) error {
+	switch shell {
+	case "bash":
+		return rootCmd.GenBashCompletionV2(out, true)
+	case "zsh":
+		return rootCmd.GenZshCompletion(out)
+	case "fish":
+		return rootCmd.GenFishCompletion(out, true)
+	}
+	return fmt.Errorf("unsupported shell %q", shell)
+}
+
+// items offered by completeItemIDs
+const (
+	allItems = iota
+	openItems
+	doneItems
+)
+
+// completeItemIDs returns a function completing the first argument
+// with the IDs of the items of the list, open or done ones only
+// as given by which, described by their task
+func completeItemIDs(which int) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) > 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		return itemIDs(which, "")
+	}
+}
+
+// completeFlagItemIDs returns a function completing a flag with item
+// IDs like completeItemIDs, whatever the arguments. The flag can
+// hold several IDs separated by commas, the last one is completed
+func completeFlagItemIDs(which int) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return itemIDs(which, toComplete[:strings.LastIndex(toComplete, ",")+1])
+	}
+}
+
+// itemIDs returns the IDs of the items which are
+// offered as completions, after prefix
+func itemIDs(which int, prefix string) ([]string, cobra.ShellCompDirective) {
+	var ids []string
+	err := readCompletions(func(ls *todo.List) {
+		for _, v := range ls.Named(listName).Items {
+			if (which == openItems && v.Done) || (which == doneItems && !v.Done) {
+				continue
+			}
+			ids = append(ids, fmt.Sprintf("%s%d\t%s", prefix, v.ID, v.Task))
+		}
+	})
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	return ids, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeListNames completes the names of the lists
+// with their item counts
+func completeListNames(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	var names []string
+	err := readCompletions(func(ls *todo.List) {
+		for _, l := range ls.Lists() {
+			names = append(names, fmt.Sprintf("%s\t%d items", l.Name, l.Items))
+		}
+	})
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
+
+// readCompletions calls fn with the list to complete from. Completing
+// must not change anything nor wait, so the list is read without the
+// storage lock, and fn isn't called when the list doesn't exist yet or
+// can't be read without asking for the passphrase
+func readCompletions(fn func(ls *todo.List)) error {
+	if _, err := os.Stat(todoFileName); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	if !canComplete() {
+		return nil
+	}
+
+	store, err := openStorage(false)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	ls := todo.List{}
+	if err := store.Get(&ls); err != nil {
+		return err
+	}
+	fn(&ls)
+	return nil
+}
+
+// canComplete reports whether the list can be read for completions
+// without asking for the passphrase, which would block the shell
+func canComplete() bool {
+	if os.Getenv(envKeyPassphrase) != "" {
+		return true
+	}
+	encrypted, err := todo.IsEncryptedFile(todoFileName)
+	return err == nil && !encrypted
+}
+
+func init() {
+	rootCmd.AddCommand(completionCmd)
+}
@@ -0,0 +1,39 @@
+/*
+Copyright © 2023 youngzy
+Copyrights apply to this source code.
+Check LICENSE for details.
+
+*/
+package cmd
+
+import (
+	"todo"
+
+	"github.com/spf13/cobra"
+)
+
+// deleteCmd represents the delete command
+var deleteCmd = &cobra.Command{
+	Use:               "delete <id>",
+	Short:             "Delete a task",
+	Aliases:           []string{"del"},
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeItemIDs(allItems),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return deleteAction(args[0])
+	},
+}
+
+func deleteAction(arg string) error {
+	id, err := parseID(arg)
+	if err != nil {
+		return err
+	}
+	return updateList("delete", func(ls *todo.List) error {
+		return ls.Delete(id)
+	})
+}
+
+func init() {
+	rootCmd.AddCommand(deleteCmd)
+}
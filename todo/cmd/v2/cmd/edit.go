@@ -0,0 +1,55 @@
+/*
+Copyright © 2023 youngzy
+Copyrights apply to this source code.
+Check LICENSE for details.
+
+*/
+package cmd
+
+import (
+	"io"
+	"os"
+	"todo"
+
+	"github.com/spf13/cobra"
+)
+
+// editCmd represents the edit command
+var editCmd = &cobra.Command{
+	Use:   "edit <id> [task]",
+	Short: "Replace the task of an item",
+	Long: `Replace the task of an item, with the new task from the
+arguments or from STDIN. From STDIN, the lines after a blank
+line replace the notes of the item.`,
+	Args:              cobra.MinimumNArgs(1),
+	ValidArgsFunction: completeItemIDs(allItems),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return editAction(os.Stdin, args[0], args[1:])
+	},
+}
+
+func editAction(in io.Reader, arg string, args []string) error {
+	id, err := parseID(arg)
+	if err != nil {
+		return err
+	}
+	t, notes, err := getTask(in, args...)
+	if err != nil {
+		return err
+	}
+
+	return updateList("edit", func(ls *todo.List) error {
+		if err := ls.Edit(id, t); err != nil {
+			return err
+		}
+		// the notes are kept unless new ones are given
+		if notes != "" {
+			return ls.SetNotes(id, notes)
+		}
+		return nil
+	})
+}
+
+func init() {
+	rootCmd.AddCommand(editCmd)
+}
@@ -0,0 +1,72 @@
+/*
+Copyright © 2023 youngzy
+Copyrights apply to this source code.
+Check LICENSE for details.
+
+*/
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"todo"
+
+	"github.com/spf13/cobra"
+)
+
+// encryptCmd represents the encrypt command
+var encryptCmd = &cobra.Command{
+	Use:   "encrypt",
+	Short: "Encrypt the list, its history, its archive and its sync state",
+	Long: `Encrypt the list, its history, its archive and its sync state
+with a passphrase from ` + envKeyPassphrase + ` or asked on the terminal.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return encryptAction(os.Stdout)
+	},
+}
+
+func encryptAction(out io.Writer) error {
+	s, err := openSession(true)
+	if err != nil {
+		return err
+	}
+	defer s.close()
+
+	// save the list, its journal and its archive again
+	// now that the key is set
+	if err := s.store.Save(s.list); err != nil {
+		return err
+	}
+	if err := s.journal.Rewrite(); err != nil {
+		return err
+	}
+	if _, err := os.Stat(todoFileName + ".archive"); err == nil {
+		arch, err := openArchive()
+		if err != nil {
+			return err
+		}
+		defer arch.Close()
+		if err := todo.Update(arch, func(*todo.List) error { return nil }); err != nil {
+			return err
+		}
+	}
+
+	if _, err := os.Stat(todoFileName + ".sync"); err == nil {
+		states := todo.SyncStates{}
+		if err := states.Load(todoFileName+".sync", todo.WithKey(listKey)); err != nil {
+			return err
+		}
+		if err := states.Save(todoFileName+".sync", todo.WithKey(listKey)); err != nil {
+			return err
+		}
+	}
+
+	_, err = fmt.Fprintf(out, "Encrypted %s\n", todoFileName)
+	return err
+}
+
+func init() {
+	rootCmd.AddCommand(encryptCmd)
+}
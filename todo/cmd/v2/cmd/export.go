@@ -0,0 +1,79 @@
+/*
+Copyright © 2023 youngzy
+Copyrights apply to this source code.
+Check LICENSE for details.
+
+*/
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"todo"
+
+	"github.com/spf13/cobra"
+)
+
+// exportCmd represents the export command
+var exportCmd = &cobra.Command{
+	Use:       "export <format>",
+	Short:     "Print the list as todo.txt, CSV or Markdown",
+	Args:      cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
+	ValidArgs: []string{todo.FormatTodoTxt, todo.FormatCSV, todo.FormatMarkdown},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return exportAction(os.Stdout, args[0])
+	},
+}
+
+// importCmd represents the import command
+var importCmd = &cobra.Command{
+	Use:   "import <file>",
+	Short: "Add the tasks in a todo.txt, .csv or .md file",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return importAction(os.Stdout, args[0])
+	},
+}
+
+func exportAction(out io.Writer, format string) error {
+	return readList(func(ls *todo.List) error {
+		named := ls.Named(listName)
+		return named.Export(out, format)
+	})
+}
+
+func importAction(out io.Writer, filename string) error {
+	f, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var n int
+	err = updateList("import", func(ls *todo.List) error {
+		n, err = ls.Import(f, todo.FormatFromFilename(filename))
+		if err != nil {
+			return err
+		}
+
+		// the items are added at the end of the list
+		for _, v := range ls.Items[len(ls.Items)-n:] {
+			if err := ls.MoveTo(v.ID, listName); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(out, "Imported %d items\n", n)
+	return err
+}
+
+func init() {
+	rootCmd.AddCommand(exportCmd)
+	rootCmd.AddCommand(importCmd)
+}
@@ -0,0 +1,99 @@
+/*
+Copyright © 2023 youngzy
+Copyrights apply to this source code.
+Check LICENSE for details.
+
+*/
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+// undoCmd represents the undo command
+var undoCmd = &cobra.Command{
+	Use:   "undo",
+	Short: "Undo the last change",
+	Long: `Undo the last change not undone yet.
+
+Archiving can't be undone, and neither can the changes made before it.`,
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return revisitAction(os.Stdout, false)
+	},
+}
+
+// redoCmd represents the redo command
+var redoCmd = &cobra.Command{
+	Use:   "redo",
+	Short: "Redo the last undone change",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return revisitAction(os.Stdout, true)
+	},
+}
+
+// historyCmd represents the history command
+var historyCmd = &cobra.Command{
+	Use:   "history",
+	Short: "Show the history of changes",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return historyAction(os.Stdout)
+	},
+}
+
+// revert, or apply again when redo is set, a recorded change
+func revisitAction(out io.Writer, redo bool) error {
+	s, err := openSession(false)
+	if err != nil {
+		return err
+	}
+	defer s.close()
+
+	revisit, verb := s.journal.Undo, "Undid"
+	if redo {
+		revisit, verb = s.journal.Redo, "Redid"
+	}
+	rev, err := revisit(s.list)
+	if err != nil {
+		return err
+	}
+
+	// record the revision only once the list is saved
+	if err := s.store.Save(s.list); err != nil {
+		return err
+	}
+	if err := s.journal.Commit(rev); err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(out, "%s %s\n", verb, rev.Target)
+	return err
+}
+
+func historyAction(out io.Writer) error {
+	s, err := openSession(false)
+	if err != nil {
+		return err
+	}
+	defer s.close()
+
+	entries, err := s.journal.Entries()
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		fmt.Fprintln(out, e)
+	}
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(undoCmd)
+	rootCmd.AddCommand(redoCmd)
+	rootCmd.AddCommand(historyCmd)
+}
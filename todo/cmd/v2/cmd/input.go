@@ -0,0 +1,135 @@
+/*
+Copyright © 2023 youngzy
+Copyrights apply to this source code.
+Check LICENSE for details.
+
+*/
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"todo"
+)
+
+// decide where to get the description for a new task
+// from arguments or STDIN. From STDIN, the first line is
+// the task and the lines after a blank line are its notes
+func getTask(r io.Reader, args ...string) (string, string, error) {
+	if len(args) > 0 {
+		return strings.Join(args, " "), "", nil
+	}
+
+	s := bufio.NewScanner(r)
+	s.Scan()
+	if err := s.Err(); err != nil {
+		return "", "", err
+	}
+	task := s.Text()
+	if len(strings.TrimSpace(task)) == 0 {
+		return "", "", fmt.Errorf("task cannot be blank")
+	}
+
+	// notes start after a blank line
+	if !s.Scan() || strings.TrimSpace(s.Text()) != "" {
+		return task, "", s.Err()
+	}
+	var notes []string
+	for s.Scan() {
+		notes = append(notes, s.Text())
+	}
+	if err := s.Err(); err != nil {
+		return "", "", err
+	}
+	return task, strings.Join(notes, "\n"), nil
+}
+
+// get the tasks to add in bulk, one per non-blank line of STDIN,
+// or a single task from the arguments
+func getTasks(r io.Reader, args ...string) ([]string, error) {
+	if len(args) > 0 {
+		return []string{strings.Join(args, " ")}, nil
+	}
+
+	var tasks []string
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		if t := strings.TrimSpace(s.Text()); t != "" {
+			tasks = append(tasks, t)
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	if len(tasks) == 0 {
+		return nil, fmt.Errorf("no tasks to add")
+	}
+	return tasks, nil
+}
+
+// set the priority, due date, tags and recurrence of the
+// item with the given ID, skipping the ones not provided
+func setDetails(ls *todo.List, id int, priority, due, tags, recur string) error {
+	if err := ls.SetPriority(id, priority); err != nil {
+		return err
+	}
+
+	if due != "" {
+		d, err := todo.ParseDue(due)
+		if err != nil {
+			return fmt.Errorf("invalid due date %q: %w", due, err)
+		}
+		if err := ls.SetDue(id, d); err != nil {
+			return err
+		}
+	}
+
+	if tags != "" {
+		if err := ls.AddTags(id, strings.Split(tags, ",")...); err != nil {
+			return err
+		}
+	}
+
+	// after the due date, which recurring items start from
+	return ls.SetRecurrence(id, recur)
+}
+
+// parse the ID of an item given as argument
+func parseID(arg string) (int, error) {
+	id, err := strconv.Atoi(arg)
+	if err != nil {
+		return 0, fmt.Errorf("invalid ID %q: item ID must be a number", arg)
+	}
+	return id, nil
+}
+
+// parse a comma-separated list of item IDs
+func parseIDs(s string) ([]int, error) {
+	var ids []int
+	for _, f := range strings.Split(s, ",") {
+		if strings.TrimSpace(f) == "" {
+			continue
+		}
+		id, err := strconv.Atoi(strings.TrimSpace(f))
+		if err != nil {
+			return nil, fmt.Errorf("invalid ID %q: %w", f, err)
+		}
+		ids = append(ids, id)
+	}
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("no item IDs given")
+	}
+	return ids, nil
+}
+
+// format item IDs separated by spaces
+func joinIDs(ids []int) string {
+	s := make([]string, len(ids))
+	for i, id := range ids {
+		s[i] = strconv.Itoa(id)
+	}
+	return strings.Join(s, " ")
+}
@@ -0,0 +1,62 @@
+/*
+Copyright © 2023 youngzy
+Copyrights apply to this source code.
+Check LICENSE for details.
+
+*/
+package cmd
+
+import (
+	"io"
+	"os"
+	"todo"
+
+	"github.com/spf13/cobra"
+)
+
+// listCmd represents the list command
+var listCmd = &cobra.Command{
+	Use:   "list [query]",
+	Short: "List the tasks",
+	Long: `List the tasks, optionally matching a query such as:
+
+  done:false tag:work due<2026-11-01 sort:due`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		format, err := cmd.Flags().GetString("format")
+		if err != nil {
+			return err
+		}
+		return listAction(os.Stdout, format, args)
+	},
+}
+
+func listAction(out io.Writer, format string, args []string) error {
+	formatter, err := todo.NewFormatter(format)
+	if err != nil {
+		return err
+	}
+	q, err := todo.NewQuery(args...)
+	if err != nil {
+		return err
+	}
+
+	return readList(func(ls *todo.List) error {
+		named := ls.Named(listName)
+		return formatter.Format(out, named.Filter(q))
+	})
+}
+
+// add the flag choosing the output format of the listed tasks
+func addFormatFlag(cmd *cobra.Command) {
+	cmd.Flags().StringP("format", "f", todo.OutputPlain,
+		"Output format of the tasks: plain, table, json or template:TEXT, such as 'template:{{.ID}} {{.Task}}'")
+	cmd.RegisterFlagCompletionFunc("format", cobra.FixedCompletions(
+		[]string{todo.OutputPlain, todo.OutputTable, todo.OutputJSON, todo.OutputTemplate},
+		cobra.ShellCompDirectiveNoFileComp|cobra.ShellCompDirectiveNoSpace))
+}
+
+func init() {
+	rootCmd.AddCommand(listCmd)
+
+	addFormatFlag(listCmd)
+}
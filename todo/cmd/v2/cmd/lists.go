@@ -0,0 +1,41 @@
+/*
+Copyright © 2023 youngzy
+Copyrights apply to this source code.
+Check LICENSE for details.
+
+*/
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"todo"
+
+	"github.com/spf13/cobra"
+)
+
+// listsCmd represents the lists command
+var listsCmd = &cobra.Command{
+	Use:   "lists",
+	Short: "Show all the lists with their item counts",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return listsAction(os.Stdout)
+	},
+}
+
+func listsAction(out io.Writer) error {
+	return readList(func(ls *todo.List) error {
+		tw := tabwriter.NewWriter(out, 0, 2, 2, ' ', 0)
+		for _, l := range ls.Lists() {
+			fmt.Fprintf(tw, "%s\t%d items\t%d done\n", l.Name, l.Items, l.Done)
+		}
+		return tw.Flush()
+	})
+}
+
+func init() {
+	rootCmd.AddCommand(listsCmd)
+}
@@ -0,0 +1,60 @@
+/*
+Copyright © 2023 youngzy
+Copyrights apply to this source code.
+Check LICENSE for details.
+
+*/
+package cmd
+
+import (
+	"fmt"
+	"todo"
+
+	"github.com/spf13/cobra"
+)
+
+// moveCmd represents the move command
+var moveCmd = &cobra.Command{
+	Use:   "move <id>",
+	Short: "Move a task to another position or list",
+	Long: `Move a task to the position given by --to, where 1 is the
+top of its list, or to the list given by --to-list.`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeItemIDs(allItems),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		to, err := cmd.Flags().GetInt("to")
+		if err != nil {
+			return err
+		}
+		toList, err := cmd.Flags().GetString("to-list")
+		if err != nil {
+			return err
+		}
+		return moveAction(args[0], to, toList)
+	},
+}
+
+func moveAction(arg string, to int, toList string) error {
+	id, err := parseID(arg)
+	if err != nil {
+		return err
+	}
+	if to == 0 && toList == "" {
+		return fmt.Errorf("either --to or --to-list is required")
+	}
+
+	return updateList("move", func(ls *todo.List) error {
+		if toList != "" {
+			return ls.MoveTo(id, toList)
+		}
+		return ls.Move(id, to)
+	})
+}
+
+func init() {
+	rootCmd.AddCommand(moveCmd)
+
+	moveCmd.Flags().Int("to", 0, "Position (1 is the top) to move the task to")
+	moveCmd.Flags().String("to-list", "", "Name of the list to move the task to")
+	moveCmd.RegisterFlagCompletionFunc("to-list", completeListNames)
+}
@@ -0,0 +1,75 @@
+/*
+Copyright © 2023 youngzy
+Copyrights apply to this source code.
+Check LICENSE for details.
+
+*/
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"todo"
+)
+
+// ask for a passphrase on the terminal, which keeps STDIN free
+// for the tasks. Echo is turned off where stty is available
+func askPassphrase(prompt string) (string, error) {
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return "", fmt.Errorf("cannot ask for the passphrase, set %s instead: %w",
+			envKeyPassphrase, err)
+	}
+	defer tty.Close()
+
+	stty(tty, "-echo")
+	defer stty(tty, "echo")
+
+	fmt.Fprint(tty, prompt)
+	line, err := bufio.NewReader(tty).ReadString('\n')
+	fmt.Fprintln(tty)
+	if err != nil {
+		return "", err
+	}
+
+	passphrase := strings.TrimRight(line, "\r\n")
+	if passphrase == "" {
+		return "", fmt.Errorf("passphrase cannot be blank")
+	}
+	return passphrase, nil
+}
+
+// get the passphrase of the list from the environment or the
+// terminal, if the list is encrypted or about to be encrypted.
+// New passphrases are asked twice
+func getPassphrase(encrypt bool) (string, error) {
+	if p := os.Getenv(envKeyPassphrase); p != "" {
+		return p, nil
+	}
+
+	encrypted, err := todo.IsEncryptedFile(todoFileName)
+	if err != nil {
+		return "", err
+	}
+	switch {
+	case encrypted:
+		return askPassphrase("Passphrase: ")
+	case !encrypt:
+		return "", nil
+	}
+
+	p, err := askPassphrase("New passphrase: ")
+	if err != nil {
+		return "", err
+	}
+	again, err := askPassphrase("Repeat passphrase: ")
+	if err != nil {
+		return "", err
+	}
+	if p != again {
+		return "", fmt.Errorf("passphrases don't match")
+	}
+	return p, nil
+}
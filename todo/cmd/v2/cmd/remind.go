@@ -0,0 +1,128 @@
+/*
+Copyright © 2023 youngzy
+Copyrights apply to this source code.
+Check LICENSE for details.
+
+*/
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+	"todo"
+
+	"distributing/notify"
+	"github.com/spf13/cobra"
+)
+
+const reminderTitle = "Todo reminder"
+
+// remindCmd represents the remind command
+var remindCmd = &cobra.Command{
+	Use:   "remind",
+	Short: "Keep running and send a notification for the tasks coming due",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dryRun, err := cmd.Flags().GetBool("dry-run")
+		if err != nil {
+			return err
+		}
+		ahead, err := cmd.Flags().GetDuration("ahead")
+		if err != nil {
+			return err
+		}
+		interval, err := cmd.Flags().GetDuration("interval")
+		if err != nil {
+			return err
+		}
+		return remindAction(os.Stdout, interval, ahead, dryRun)
+	},
+}
+
+func remindAction(out io.Writer, interval, ahead time.Duration, dryRun bool) error {
+	store, err := openStorage(false)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	// the reminders only read the list, without the
+	// storage lock so it can be changed meanwhile
+	return watchReminders(out, store, interval, ahead, dryRun)
+}
+
+// the reminder last sent for an item
+type sentReminder struct {
+	due      time.Time
+	severity notify.Severity
+}
+
+// watch the list every interval and send a notification for each
+// open item due within ahead, again when it gets more overdue.
+// Dry runs print the notifications to w instead of sending them
+func watchReminders(w io.Writer, store todo.Storage, interval, ahead time.Duration, dryRun bool) error {
+	ls := &todo.List{}
+	sent := map[int]sentReminder{}
+
+	// read the list again only when the storage changes, or
+	// every time when it can't tell. The version is taken before
+	// reading the list so changes made meanwhile aren't missed
+	v, versioned := store.(todo.Versioner)
+	var version string
+	loaded := false
+
+	for {
+		current := ""
+		if versioned {
+			var err error
+			if current, err = v.Version(); err != nil {
+				return err
+			}
+		}
+		if !loaded || !versioned || current != version {
+			if err := store.Get(ls); err != nil {
+				return err
+			}
+			version, loaded = current, true
+		}
+
+		for _, r := range ls.Reminders(time.Now(), ahead) {
+			sev := severity(r.Overdue)
+			if s, ok := sent[r.ID]; ok && s.due.Equal(r.Due) && s.severity >= sev {
+				continue
+			}
+
+			if dryRun {
+				fmt.Fprintf(w, "[%s] %s: %s\n", sev, reminderTitle, r)
+			} else if err := notify.New(reminderTitle, r.String(), sev).Send(); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				continue
+			}
+			sent[r.ID] = sentReminder{due: r.Due, severity: sev}
+		}
+
+		time.Sleep(interval)
+	}
+}
+
+// severity of the notification for an item overdue for the given time,
+// negative for items not due yet
+func severity(overdue time.Duration) notify.Severity {
+	switch {
+	case overdue < 0:
+		return notify.SeverityLow
+	case overdue < 24*time.Hour:
+		return notify.SeverityNormal
+	}
+	return notify.SeverityUrgent
+}
+
+func init() {
+	rootCmd.AddCommand(remindCmd)
+
+	remindCmd.Flags().Bool("dry-run", false, "Print the notifications instead of sending them")
+	remindCmd.Flags().Duration("ahead", 15*time.Minute, "How long before the due time to send a notification")
+	remindCmd.Flags().Duration("interval", time.Minute, "How often to check the list")
+}
@@ -0,0 +1,38 @@
+/*
+Copyright © 2023 youngzy
+Copyrights apply to this source code.
+Check LICENSE for details.
+
+*/
+package cmd
+
+import (
+	"todo"
+
+	"github.com/spf13/cobra"
+)
+
+// reopenCmd represents the reopen command
+var reopenCmd = &cobra.Command{
+	Use:               "reopen <id>",
+	Short:             "Mark a completed task as not done",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeItemIDs(doneItems),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return reopenAction(args[0])
+	},
+}
+
+func reopenAction(arg string) error {
+	id, err := parseID(arg)
+	if err != nil {
+		return err
+	}
+	return updateList("reopen", func(ls *todo.List) error {
+		return ls.Reopen(id)
+	})
+}
+
+func init() {
+	rootCmd.AddCommand(reopenCmd)
+}
@@ -0,0 +1,67 @@
+/*
+Copyright © 2023 youngzy
+Copyrights apply to this source code.
+Check LICENSE for details.
+
+*/
+package cmd
+
+import (
+	"os"
+	"todo"
+
+	"github.com/spf13/cobra"
+)
+
+// default file name
+var todoFileName = ".todo.json"
+
+// storage kind, a JSON file by default
+var todoStorage = todo.StorageJSON
+
+// name of the list the commands work on
+var listName = todo.DefaultList
+
+const (
+	envKeyFilename = "TODO_FILENAME"
+	envKeyStorage  = "TODO_STORAGE"
+	// passphrase of encrypted lists
+	envKeyPassphrase = "TODO_PASSPHRASE"
+	// API token of the todoServer to sync with
+	envKeyToken = "TODO_TOKEN"
+)
+
+// rootCmd represents the base command when called without any subcommands
+var rootCmd = &cobra.Command{
+	Use:   "todo",
+	Short: "Manage a todo list",
+	Long: `Todo manages a todo list kept in a local file.
+
+Add tasks with the add command, list them with the list command
+and complete them with the complete command. The tui command
+opens an interactive view of the list.
+
+The list is kept in .todo.json, or in the file given by
+` + envKeyFilename + `, with the storage given by ` + envKeyStorage + `.`,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+// Execute adds all child commands to the root command and sets flags appropriately.
+// This is called by main.main(). It only needs to happen once to the rootCmd.
+func Execute() {
+	cobra.CheckErr(rootCmd.Execute())
+}
+
+func init() {
+	if os.Getenv(envKeyFilename) != "" {
+		todoFileName = os.Getenv(envKeyFilename)
+	}
+	if os.Getenv(envKeyStorage) != "" {
+		todoStorage = os.Getenv(envKeyStorage)
+	}
+
+	rootCmd.PersistentFlags().StringVarP(&listName, "list-name", "l", todo.DefaultList,
+		"Name of the list to work on")
+	rootCmd.RegisterFlagCompletionFunc("list-name", completeListNames)
+}
@@ -0,0 +1,69 @@
+/*
+Copyright © 2023 youngzy
+Copyrights apply to this source code.
+Check LICENSE for details.
+
+*/
+package cmd
+
+import (
+	"io"
+	"os"
+	"strings"
+	"todo"
+
+	"github.com/spf13/cobra"
+)
+
+// searchCmd represents the search command
+var searchCmd = &cobra.Command{
+	Use:   "search <text>",
+	Short: "Show the tasks whose text or notes contain the given text",
+	Args:  cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		word, err := cmd.Flags().GetBool("word")
+		if err != nil {
+			return err
+		}
+		regexp, err := cmd.Flags().GetBool("regexp")
+		if err != nil {
+			return err
+		}
+		mode := todo.SearchSubstring
+		switch {
+		case regexp:
+			mode = todo.SearchRegexp
+		case word:
+			mode = todo.SearchWord
+		}
+		format, err := cmd.Flags().GetString("format")
+		if err != nil {
+			return err
+		}
+		return searchAction(os.Stdout, format, strings.Join(args, " "), mode)
+	},
+}
+
+func searchAction(out io.Writer, format, pattern string, mode string) error {
+	formatter, err := todo.NewFormatter(format)
+	if err != nil {
+		return err
+	}
+
+	return readList(func(ls *todo.List) error {
+		named := ls.Named(listName)
+		found, err := named.Search(pattern, mode)
+		if err != nil {
+			return err
+		}
+		return formatter.Format(out, found)
+	})
+}
+
+func init() {
+	rootCmd.AddCommand(searchCmd)
+
+	searchCmd.Flags().BoolP("word", "w", false, "Match whole words only")
+	searchCmd.Flags().BoolP("regexp", "r", false, "Match a regular expression")
+	addFormatFlag(searchCmd)
+}
@@ -0,0 +1,131 @@
+/*
+Copyright © 2023 youngzy
+Copyrights apply to this source code.
+Check LICENSE for details.
+
+*/
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"todo"
+)
+
+// session is the list a command works on, loaded from the storage.
+// It holds the storage lock until it's closed so other todo
+// processes can't change the list between loading and saving it
+type session struct {
+	store   todo.Storage
+	unlock  func() error
+	journal *todo.Journal
+	list    *todo.List
+	// the list as loaded, to record the changes
+	before todo.List
+}
+
+// the key of the list, nil when it isn't encrypted, and whether
+// it's set, to ask for the passphrase only once when the list
+// is opened several times
+var (
+	listKey    *todo.Key
+	listKeySet bool
+)
+
+// openStorage sets the key of the list, asking for a new passphrase
+// when encrypt is set, and returns the storage of the list
+func openStorage(encrypt bool) (todo.Storage, error) {
+	if !listKeySet {
+		// encrypted lists are transparently decrypted and
+		// encrypted again when saved
+		passphrase, err := getPassphrase(encrypt)
+		if err != nil {
+			return nil, err
+		}
+		if passphrase != "" && !strings.EqualFold(todoStorage, todo.StorageJSON) {
+			return nil, fmt.Errorf("encryption needs the %s storage", todo.StorageJSON)
+		}
+		listKey = todo.NewKey(passphrase)
+		listKeySet = true
+	}
+
+	return todo.NewStorage(todoStorage, todoFileName, todo.WithKey(listKey))
+}
+
+// openSession locks the storage and loads the list
+func openSession(encrypt bool) (*session, error) {
+	store, err := openStorage(encrypt)
+	if err != nil {
+		return nil, err
+	}
+
+	unlock, err := store.Lock()
+	if err != nil {
+		store.Close()
+		return nil, err
+	}
+
+	s := &session{
+		store:  store,
+		unlock: unlock,
+		// changes are recorded in a journal next to the list
+		// to be able to undo them
+		journal: todo.NewJournal(todoFileName+".journal", todo.WithKey(listKey)),
+		list:    &todo.List{},
+	}
+	if err := store.Get(s.list); err != nil {
+		s.close()
+		return nil, err
+	}
+	s.before = s.list.Clone()
+	return s, nil
+}
+
+// save the list and record the change made by op in the journal
+func (s *session) save(op string) error {
+	if err := s.store.Save(s.list); err != nil {
+		return err
+	}
+	return s.record(op)
+}
+
+// record the change made by op in the journal, once the list is saved
+func (s *session) record(op string) error {
+	if err := s.journal.Record(op, s.before, *s.list); err != nil {
+		return err
+	}
+	s.before = s.list.Clone()
+	return nil
+}
+
+// close releases the storage lock
+func (s *session) close() {
+	s.unlock()
+	s.store.Close()
+}
+
+// readList calls fn with the list
+func readList(fn func(ls *todo.List) error) error {
+	s, err := openSession(false)
+	if err != nil {
+		return err
+	}
+	defer s.close()
+
+	return fn(s.list)
+}
+
+// updateList calls fn to change the list, then saves it
+// recording the change as op, unless fn fails
+func updateList(op string, fn func(ls *todo.List) error) error {
+	s, err := openSession(false)
+	if err != nil {
+		return err
+	}
+	defer s.close()
+
+	if err := fn(s.list); err != nil {
+		return err
+	}
+	return s.save(op)
+}
@@ -0,0 +1,95 @@
+/*
+Copyright © 2023 youngzy
+Copyrights apply to this source code.
+Check LICENSE for details.
+
+*/
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"todo"
+
+	"github.com/spf13/cobra"
+)
+
+// showCmd represents the show command
+var showCmd = &cobra.Command{
+	Use:               "show <id>",
+	Short:             "Show all the details of a task",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeItemIDs(allItems),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return showAction(os.Stdout, args[0])
+	},
+}
+
+func showAction(out io.Writer, arg string) error {
+	id, err := parseID(arg)
+	if err != nil {
+		return err
+	}
+	return readList(func(ls *todo.List) error {
+		return printItem(out, ls, id)
+	})
+}
+
+// print all the details of the item with the given ID
+func printItem(w io.Writer, ls *todo.List, id int) error {
+	i, err := ls.IndexOf(id)
+	if err != nil {
+		return err
+	}
+	v := ls.Items[i]
+
+	const timeFormat = "2006-01-02 15:04"
+	tw := tabwriter.NewWriter(w, 12, 2, 0, ' ', 0)
+	fmt.Fprintf(tw, "ID:\t%d\n", v.ID)
+	fmt.Fprintf(tw, "Task:\t%s\n", v.Task)
+	if v.ListName != "" {
+		fmt.Fprintf(tw, "List:\t%s\n", v.ListName)
+	}
+	fmt.Fprintf(tw, "Created:\t%s\n", v.CreateAt.Format(timeFormat))
+	if v.Done {
+		fmt.Fprintf(tw, "Completed:\t%s\n", v.CompletedAt.Format(timeFormat))
+	} else {
+		fmt.Fprintf(tw, "Completed:\t%s\n", "No")
+	}
+	if v.Priority != "" {
+		fmt.Fprintf(tw, "Priority:\t%s\n", v.Priority)
+	}
+	if !v.Due.IsZero() {
+		fmt.Fprintf(tw, "Due:\t%s\n", todo.FormatDue(v.Due))
+	}
+	if v.Recur != "" {
+		fmt.Fprintf(tw, "Repeats:\t%s\n", v.Recur)
+	}
+	if len(v.Tags) > 0 {
+		fmt.Fprintf(tw, "Tags:\t%s\n", strings.Join(v.Tags, " "))
+	}
+	if v.Parent != 0 {
+		fmt.Fprintf(tw, "Parent:\t%d\n", v.Parent)
+	}
+	if subtasks := ls.Subtasks(v.ID); len(subtasks) > 0 {
+		fmt.Fprintf(tw, "Subtasks:\t%s\n", joinIDs(subtasks))
+	}
+	if len(v.BlockedBy) > 0 {
+		fmt.Fprintf(tw, "Blocked by:\t%s\n", joinIDs(v.BlockedBy))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	if v.Notes != "" {
+		fmt.Fprintf(w, "\n%s\n", v.Notes)
+	}
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(showCmd)
+}
@@ -0,0 +1,91 @@
+/*
+Copyright © 2023 youngzy
+Copyrights apply to this source code.
+Check LICENSE for details.
+
+*/
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+	"todo"
+
+	"github.com/spf13/cobra"
+)
+
+// statsCmd represents the stats command
+var statsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Show statistics about the completed and open tasks",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		jsonOut, err := cmd.Flags().GetBool("json")
+		if err != nil {
+			return err
+		}
+		return statsAction(os.Stdout, jsonOut)
+	},
+}
+
+func statsAction(out io.Writer, jsonOut bool) error {
+	return readList(func(ls *todo.List) error {
+		named := ls.Named(listName)
+		s := named.Stats(time.Now())
+		if jsonOut {
+			return json.NewEncoder(out).Encode(s)
+		}
+		return printStats(out, s)
+	})
+}
+
+// print the statistics of a list as a report
+func printStats(w io.Writer, s todo.Stats) error {
+	tw := tabwriter.NewWriter(w, 0, 2, 2, ' ', 0)
+	fmt.Fprintf(tw, "Open:\t%d\n", s.Open)
+	fmt.Fprintf(tw, "Done:\t%d\n", s.Done)
+	fmt.Fprintf(tw, "Median time to complete:\t%s\n", formatDuration(s.MedianTimeToComplete))
+
+	fmt.Fprintln(tw, "\nCompleted per day:")
+	for _, c := range s.PerDay {
+		fmt.Fprintf(tw, "  %s %s\t%d\n", c.Start.Format(todo.DateFormat), c.Start.Format("Mon"), c.Count)
+	}
+	fmt.Fprintln(tw, "\nCompleted per week:")
+	for _, c := range s.PerWeek {
+		fmt.Fprintf(tw, "  %s\t%d\n", c.Start.Format(todo.DateFormat), c.Count)
+	}
+
+	if len(s.OldestOpen.Items) > 0 {
+		fmt.Fprintln(tw, "\nOldest open:")
+		for _, v := range s.OldestOpen.Items {
+			fmt.Fprintf(tw, "  %d: %s\t%s old\n", v.ID, v.Task,
+				formatDuration(time.Since(v.CreateAt)))
+		}
+	}
+	return tw.Flush()
+}
+
+// format a duration in days and hours, or in minutes
+// for durations shorter than an hour
+func formatDuration(d time.Duration) string {
+	switch {
+	case d <= 0:
+		return "-"
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	}
+	days := int(d.Hours()) / 24
+	return fmt.Sprintf("%dd %dh", days, int(d.Hours())-24*days)
+}
+
+func init() {
+	rootCmd.AddCommand(statsCmd)
+
+	statsCmd.Flags().Bool("json", false, "Print the statistics as JSON")
+}
@@ -0,0 +1,268 @@
+/*
+Copyright © 2023 youngzy
+Copyrights apply to this source code.
+Check LICENSE for details.
+
+*/
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+	"todo"
+
+	"github.com/spf13/cobra"
+)
+
+// syncCmd represents the sync command
+var syncCmd = &cobra.Command{
+	Use:   "sync <url>",
+	Short: "Sync the list with a todoServer",
+	Long: `Sync the list with the list of the same name on the todoServer
+at the given URL, such as http://localhost:8080.
+
+Items added, edited, completed, reopened or deleted on either side
+since the last sync are changed on the other one. A task edited on
+both sides takes the server's task, and an item deleted on one side
+but changed on the other is kept. These conflicts are reported.
+
+A server requiring authentication is given the API token with
+--token or ` + envKeyToken + `.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		token, err := cmd.Flags().GetString("token")
+		if err != nil {
+			return err
+		}
+		return syncAction(os.Stdout, args[0], token)
+	},
+}
+
+func syncAction(out io.Writer, apiRoot, token string) error {
+	apiRoot = strings.TrimSuffix(apiRoot, "/")
+
+	s, err := openSession(false)
+	if err != nil {
+		return err
+	}
+	defer s.close()
+
+	// the state of the lists after their last sync
+	// is kept next to them
+	stateFile := todoFileName + ".sync"
+	states := todo.SyncStates{}
+	if err := states.Load(stateFile, todo.WithKey(listKey)); err != nil {
+		return err
+	}
+	state := states.Find(apiRoot, listName)
+
+	report, err := s.list.Sync(newServerRemote(apiRoot, listName, token), listName, state)
+	if err != nil {
+		return err
+	}
+	if err := s.save("sync"); err != nil {
+		return err
+	}
+	if err := states.Save(stateFile, todo.WithKey(listKey)); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "Sent %d changes, received %d changes\n", report.Sent, report.Received)
+	for _, c := range report.Conflicts {
+		fmt.Fprintf(out, "Conflict: %s\n", c)
+	}
+	return nil
+}
+
+// serverRemote is a named list on a todoServer
+type serverRemote struct {
+	client *http.Client
+	// URL of the items of the list
+	url string
+	// API token, if the server needs one
+	token string
+}
+
+func newServerRemote(apiRoot, name, token string) *serverRemote {
+	u := apiRoot + "/todo"
+	if name != todo.DefaultList {
+		u = fmt.Sprintf("%s/lists/%s/todo", apiRoot, url.PathEscape(name))
+	}
+	return &serverRemote{
+		client: &http.Client{Timeout: 10 * time.Second},
+		url:    u,
+		token:  token,
+	}
+}
+
+// serverError is a response from the server
+// without the expected status
+type serverError struct {
+	status  int
+	message string
+}
+
+func (e *serverError) Error() string {
+	return fmt.Sprintf("server error: %d %s", e.status, e.message)
+}
+
+// Items returns the items of the list on the server
+func (s *serverRemote) Items() (todo.List, error) {
+	var resp struct {
+		Results todo.List `json:"results"`
+	}
+	if _, err := s.do(http.MethodGet, s.url, nil, http.StatusOK, &resp); err != nil {
+		return todo.List{}, err
+	}
+	return resp.Results, nil
+}
+
+// Add adds a task to the list on the server, which gives
+// the location of the new item to get its ID and creation time
+func (s *serverRemote) Add(task string) (int, time.Time, error) {
+	body := struct {
+		Task string `json:"task"`
+	}{task}
+	header, err := s.do(http.MethodPost, s.url, body, http.StatusCreated, nil)
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+
+	id, err := strconv.Atoi(path.Base(header.Get("Location")))
+	if err != nil {
+		return 0, time.Time{}, fmt.Errorf("server gave no location for the new item %q", task)
+	}
+	var resp struct {
+		Results todo.List `json:"results"`
+	}
+	if _, err := s.do(http.MethodGet, s.itemURL(id), nil, http.StatusOK, &resp); err != nil {
+		return 0, time.Time{}, err
+	}
+	if len(resp.Results.Items) != 1 {
+		return 0, time.Time{}, fmt.Errorf("server returned %d items for item %d", len(resp.Results.Items), id)
+	}
+	return id, resp.Results.Items[0].CreateAt, nil
+}
+
+// Edit replaces the task of an item on the server
+func (s *serverRemote) Edit(id int, task string) error {
+	body := struct {
+		Task string `json:"task"`
+	}{task}
+	_, err := s.do(http.MethodPatch, s.itemURL(id), body, http.StatusNoContent, nil)
+	return err
+}
+
+// SetDone completes or reopens an item on the server
+func (s *serverRemote) SetDone(id int, done bool) error {
+	u := s.itemURL(id) + "?reopen"
+	if done {
+		u = s.itemURL(id) + "?complete"
+	}
+	_, err := s.do(http.MethodPatch, u, nil, http.StatusNoContent, nil)
+
+	// the server refuses to complete the blocked items
+	var se *serverError
+	if errors.As(err, &se) && se.status == http.StatusConflict {
+		return fmt.Errorf("%w on the server: %s", todo.ErrBlocked, se.message)
+	}
+	return err
+}
+
+// Delete deletes an item on the server, if it's still there
+func (s *serverRemote) Delete(id int) error {
+	_, err := s.do(http.MethodDelete, s.itemURL(id), nil, http.StatusNoContent, nil)
+
+	var se *serverError
+	if errors.As(err, &se) && se.status == http.StatusNotFound {
+		return nil
+	}
+	return err
+}
+
+// MoveTo moves an item to another named list on the server
+func (s *serverRemote) MoveTo(id int, name string) error {
+	body := struct {
+		List string `json:"list"`
+	}{name}
+	_, err := s.do(http.MethodPatch, s.itemURL(id), body, http.StatusNoContent, nil)
+	return err
+}
+
+func (s *serverRemote) itemURL(id int) string {
+	return fmt.Sprintf("%s/%d", s.url, id)
+}
+
+// do sends a request with body encoded as JSON, checking the response
+// has the expected status and decoding its JSON body into resp if given
+func (s *serverRemote) do(method, u string, body interface{}, expStatus int,
+	resp interface{}) (http.Header, error) {
+	var b bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&b).Encode(body); err != nil {
+			return nil, err
+		}
+	}
+
+	req, err := http.NewRequest(method, u, &b)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if s.token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.token)
+	}
+
+	r, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Body.Close()
+
+	if r.StatusCode != expStatus {
+		msg, err := io.ReadAll(r.Body)
+		if err != nil {
+			return nil, err
+		}
+		se := &serverError{status: r.StatusCode, message: strings.TrimSpace(string(msg))}
+
+		// the server replies with a JSON error envelope
+		var e struct {
+			Error struct {
+				Message string `json:"message"`
+				Details string `json:"details"`
+			} `json:"error"`
+		}
+		if json.Unmarshal(msg, &e) == nil && e.Error.Message != "" {
+			se.message = e.Error.Message
+			if e.Error.Details != "" {
+				se.message = e.Error.Details
+			}
+		}
+		return nil, se
+	}
+	if resp != nil {
+		if err := json.NewDecoder(r.Body).Decode(resp); err != nil {
+			return nil, fmt.Errorf("invalid server response: %w", err)
+		}
+	}
+	return r.Header, nil
+}
+
+func init() {
+	rootCmd.AddCommand(syncCmd)
+
+	syncCmd.Flags().String("token", os.Getenv(envKeyToken), "API token of the server")
+}
@@ -0,0 +1,384 @@
+/*
+Copyright © 2023 youngzy
+Copyrights apply to this source code.
+Check LICENSE for details.
+
+*/
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"todo"
+
+	"github.com/spf13/cobra"
+)
+
+// tuiCmd represents the tui command
+var tuiCmd = &cobra.Command{
+	Use:   "tui",
+	Short: "Browse and change the list interactively",
+	Long: `Browse and change the list interactively in the terminal.
+
+Keys:
+  j, down     select the next task
+  k, up       select the previous task
+  space, x    complete the selected task, or reopen it when done
+  a           add a task, typed and confirmed with enter
+  d           delete the selected task, confirmed with y
+  r           load the list again
+  q, esc      quit`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return tuiAction()
+	},
+}
+
+func tuiAction() error {
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return fmt.Errorf("the interactive mode needs a terminal: %w", err)
+	}
+	defer tty.Close()
+
+	// load the list before the raw mode, asking for
+	// the passphrase if needed
+	t, err := newTUI(changeList)
+	if err != nil {
+		return err
+	}
+
+	restore, err := rawMode(tty)
+	if err != nil {
+		return err
+	}
+	defer restore()
+
+	// use the alternate screen, without the cursor
+	fmt.Fprint(tty, "\x1b[?1049h\x1b[?25l")
+	defer fmt.Fprint(tty, "\x1b[?25h\x1b[?1049l")
+	if size, err := stty(tty, "size"); err == nil {
+		rows, _ := strconv.Atoi(strings.Fields(size + " 0")[0])
+		t.height = rows - tuiLines
+	}
+
+	r := bufio.NewReader(tty)
+	for {
+		t.render(tty)
+		key, err := readKey(r)
+		if err != nil {
+			return err
+		}
+		if t.handle(key) {
+			return nil
+		}
+	}
+}
+
+// changeList applies fn to the list, saving it as the op
+// change, and returns the items of the current named list.
+// A nil fn only loads the list
+func changeList(op string, fn func(ls *todo.List) error) (todo.List, error) {
+	var items todo.List
+	named := func(ls *todo.List) error {
+		if fn != nil {
+			if err := fn(ls); err != nil {
+				return err
+			}
+		}
+		items = ls.Named(listName)
+		return nil
+	}
+
+	var err error
+	if fn == nil {
+		err = readList(named)
+	} else {
+		err = updateList(op, named)
+	}
+	return items, err
+}
+
+// keys read from the terminal, besides the printable characters
+const (
+	keyUp        = "up"
+	keyDown      = "down"
+	keyEnter     = "enter"
+	keyBackspace = "backspace"
+	keyEsc       = "esc"
+	keyCtrlC     = "ctrl-c"
+)
+
+// lines of the screen besides the items
+const tuiLines = 5
+
+// tui is the interactive view of a list. Every change goes through
+// change, which applies it to the list, holding the storage lock
+// only meanwhile, and returns the items to show
+type tui struct {
+	items  todo.List
+	cursor int
+	// first item shown and how many fit on the screen,
+	// all of them when height isn't positive
+	top    int
+	height int
+	// task being typed while adding
+	adding bool
+	input  []rune
+	// ID of the item to delete once confirmed
+	deleting int
+	status   string
+	change   func(op string, fn func(ls *todo.List) error) (todo.List, error)
+}
+
+func newTUI(change func(op string, fn func(ls *todo.List) error) (todo.List, error)) (*tui, error) {
+	items, err := change("", nil)
+	if err != nil {
+		return nil, err
+	}
+	return &tui{items: items, change: change}, nil
+}
+
+// handle a key, reporting whether to quit
+func (t *tui) handle(key string) bool {
+	if key == keyCtrlC {
+		return true
+	}
+	t.status = ""
+
+	switch {
+	case t.adding:
+		t.edit(key)
+		return false
+	case t.deleting != 0:
+		id := t.deleting
+		t.deleting = 0
+		if key == "y" {
+			t.apply("delete", func(ls *todo.List) error {
+				return ls.Delete(id)
+			})
+		}
+		return false
+	}
+
+	switch key {
+	case "q", keyEsc:
+		return true
+	case "k", keyUp:
+		if t.cursor > 0 {
+			t.cursor--
+		}
+	case "j", keyDown:
+		if t.cursor < len(t.items.Items)-1 {
+			t.cursor++
+		}
+	case " ", "x", keyEnter:
+		id := t.selectedID()
+		if id == 0 {
+			break
+		}
+		if t.items.Items[t.cursor].Done {
+			t.apply("reopen", func(ls *todo.List) error {
+				return ls.Reopen(id)
+			})
+		} else {
+			t.apply("complete", func(ls *todo.List) error {
+				return ls.Complete(id)
+			})
+		}
+	case "a":
+		t.adding = true
+		t.input = nil
+	case "d":
+		t.deleting = t.selectedID()
+	case "r":
+		t.apply("", nil)
+	}
+	return false
+}
+
+// edit the task being added
+func (t *tui) edit(key string) {
+	switch key {
+	case keyEsc:
+		t.adding = false
+	case keyEnter:
+		t.adding = false
+		task := strings.TrimSpace(string(t.input))
+		if task == "" {
+			return
+		}
+		var id int
+		t.apply("add", func(ls *todo.List) error {
+			id = ls.Add(task)
+			return ls.MoveTo(id, listName)
+		})
+		t.selectID(id)
+	case keyBackspace:
+		if len(t.input) > 0 {
+			t.input = t.input[:len(t.input)-1]
+		}
+	default:
+		if r := []rune(key); len(r) == 1 && r[0] >= ' ' {
+			t.input = append(t.input, r[0])
+		}
+	}
+}
+
+// apply a change, keeping the selected item when it's still there
+func (t *tui) apply(op string, fn func(ls *todo.List) error) {
+	id := t.selectedID()
+	items, err := t.change(op, fn)
+	if err != nil {
+		t.status = err.Error()
+		return
+	}
+	t.items = items
+	t.selectID(id)
+}
+
+// selectedID returns the ID of the selected item,
+// 0 when there are no items
+func (t *tui) selectedID() int {
+	if t.cursor >= len(t.items.Items) {
+		return 0
+	}
+	return t.items.Items[t.cursor].ID
+}
+
+// selectID moves the cursor to the item with the given ID,
+// or within the items when there's no such item
+func (t *tui) selectID(id int) {
+	if i, err := t.items.IndexOf(id); err == nil {
+		t.cursor = i
+	}
+	if t.cursor >= len(t.items.Items) {
+		t.cursor = len(t.items.Items) - 1
+	}
+	if t.cursor < 0 {
+		t.cursor = 0
+	}
+}
+
+// render the screen, with the lines ended by "\r\n"
+// as the terminal is in raw mode
+func (t *tui) render(w io.Writer) {
+	var b strings.Builder
+	b.WriteString("\x1b[H\x1b[2J")
+	fmt.Fprintf(&b, "Todo list: %s\r\n\r\n", listName)
+
+	// scroll to keep the selected item on the screen
+	first, last := 0, len(t.items.Items)
+	if t.height > 0 {
+		if t.cursor < t.top {
+			t.top = t.cursor
+		}
+		if t.cursor >= t.top+t.height {
+			t.top = t.cursor - t.height + 1
+		}
+		first = t.top
+		if first+t.height < last {
+			last = first + t.height
+		}
+	}
+	if len(t.items.Items) == 0 {
+		b.WriteString("  No tasks, press a to add one\r\n")
+	}
+	for i := first; i < last; i++ {
+		v := t.items.Items[i]
+		cursor, done := " ", " "
+		if i == t.cursor {
+			cursor = ">"
+		}
+		if v.Done {
+			done = "X"
+		}
+		fmt.Fprintf(&b, "%s [%s] %d: %s\r\n", cursor, done, v.ID, v.Task)
+	}
+
+	b.WriteString("\r\n")
+	switch {
+	case t.adding:
+		fmt.Fprintf(&b, "New task: %s_\r\n", string(t.input))
+	case t.deleting != 0:
+		fmt.Fprintf(&b, "Delete task %d? (y/n)\r\n", t.deleting)
+	case t.status != "":
+		fmt.Fprintf(&b, "%s\r\n", t.status)
+	default:
+		b.WriteString("j/k move  space toggle  a add  d delete  r reload  q quit\r\n")
+	}
+	io.WriteString(w, b.String())
+}
+
+// read a key from a terminal in raw mode
+func readKey(r *bufio.Reader) (string, error) {
+	c, err := r.ReadByte()
+	if err != nil {
+		return "", err
+	}
+
+	switch c {
+	case 3:
+		return keyCtrlC, nil
+	case '\r', '\n':
+		return keyEnter, nil
+	case 127, '\b':
+		return keyBackspace, nil
+	case 27:
+		// arrow keys come as ESC [ A in a single read,
+		// while a lone ESC is the escape key
+		if r.Buffered() < 2 {
+			return keyEsc, nil
+		}
+		seq := make([]byte, 2)
+		if _, err := io.ReadFull(r, seq); err != nil {
+			return "", err
+		}
+		switch string(seq) {
+		case "[A", "OA":
+			return keyUp, nil
+		case "[B", "OB":
+			return keyDown, nil
+		}
+		return keyEsc, nil
+	}
+
+	if err := r.UnreadByte(); err != nil {
+		return "", err
+	}
+	ch, _, err := r.ReadRune()
+	if err != nil {
+		return "", err
+	}
+	return string(ch), nil
+}
+
+// rawMode puts the terminal in raw mode, without echo,
+// returning a function restoring the previous mode
+func rawMode(tty *os.File) (func(), error) {
+	state, err := stty(tty, "-g")
+	if err != nil {
+		return nil, fmt.Errorf("cannot set the terminal mode: %w", err)
+	}
+	if _, err := stty(tty, "raw", "-echo"); err != nil {
+		return nil, fmt.Errorf("cannot set the terminal mode: %w", err)
+	}
+	return func() { stty(tty, state) }, nil
+}
+
+// run stty on the terminal, returning its output
+func stty(tty *os.File, args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = tty
+	out, err := cmd.Output()
+	return strings.TrimSpace(string(out)), err
+}
+
+func init() {
+	rootCmd.AddCommand(tuiCmd)
+}
@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+	"todo"
+)
+
+// newTestTUI returns a tui changing ls in memory
+func newTestTUI(t *testing.T, ls *todo.List) *tui {
+	t.Helper()
+	change := func(op string, fn func(ls *todo.List) error) (todo.List, error) {
+		if fn != nil {
+			if err := fn(ls); err != nil {
+				return todo.List{}, err
+			}
+		}
+		return ls.Named(listName), nil
+	}
+
+	tu, err := newTUI(change)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tu
+}
+
+// press the keys, reporting whether the last one quits
+func press(tu *tui, keys ...string) bool {
+	var quit bool
+	for _, k := range keys {
+		quit = tu.handle(k)
+	}
+	return quit
+}
+
+func TestTUI_Browse(t *testing.T) {
+	ls := &todo.List{}
+	ls.Add("task 1")
+	ls.Add("task 2")
+	tu := newTestTUI(t, ls)
+
+	press(tu, "j", "j", keyDown)
+	if tu.cursor != 1 {
+		t.Errorf("Expected cursor at 1, got %d", tu.cursor)
+	}
+	var b strings.Builder
+	tu.render(&b)
+	if !strings.Contains(b.String(), "  [ ] 1: task 1\r\n> [ ] 2: task 2\r\n") {
+		t.Errorf("Unexpected screen %q", b.String())
+	}
+
+	press(tu, "k", keyUp)
+	if tu.cursor != 0 {
+		t.Errorf("Expected cursor at 0, got %d", tu.cursor)
+	}
+	if !press(tu, "q") {
+		t.Error("Expected q to quit")
+	}
+}
+
+func TestTUI_ToggleAddDelete(t *testing.T) {
+	ls := &todo.List{}
+	ls.Add("task 1")
+	id := ls.Add("task 2")
+	ls.Block(id, 1)
+	tu := newTestTUI(t, ls)
+
+	t.Run("Toggle", func(t *testing.T) {
+		press(tu, " ")
+		if !ls.Items[0].Done {
+			t.Fatal("Expected task 1 to be completed")
+		}
+		press(tu, "x")
+		if ls.Items[0].Done {
+			t.Fatal("Expected task 1 to be reopened")
+		}
+	})
+
+	t.Run("Blocked", func(t *testing.T) {
+		press(tu, "j", " ")
+		if ls.Items[1].Done || !strings.Contains(tu.status, "blocked") {
+			t.Errorf("Expected blocked error, got status %q", tu.status)
+		}
+	})
+
+	t.Run("Add", func(t *testing.T) {
+		press(tu, "a", "b", "u", "y", "y", keyBackspace, " ", "m", "i", "l", "k", keyEnter)
+		if len(ls.Items) != 3 || ls.Items[2].Task != "buy milk" {
+			t.Fatalf("Expected task added, got:\n%s", ls)
+		}
+		if tu.cursor != 2 {
+			t.Errorf("Expected new task selected, got cursor %d", tu.cursor)
+		}
+
+		// escape cancels the task being typed
+		press(tu, "a", "x", keyEsc)
+		if len(ls.Items) != 3 || tu.adding {
+			t.Errorf("Expected no task added, got:\n%s", ls)
+		}
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		press(tu, "d", "n")
+		if len(ls.Items) != 3 {
+			t.Fatal("Expected delete to be cancelled")
+		}
+		press(tu, "d", "y")
+		if len(ls.Items) != 2 || tu.cursor != 1 {
+			t.Errorf("Expected task deleted with cursor at 1, got cursor %d:\n%s", tu.cursor, ls)
+		}
+	})
+}
+
+func TestReadKey(t *testing.T) {
+	r := bufio.NewReader(strings.NewReader("j\x1b[A\x1b[Bé\r\x7f\x03"))
+	exp := []string{"j", keyUp, keyDown, "é", keyEnter, keyBackspace, keyCtrlC}
+	for _, e := range exp {
+		k, err := readKey(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if k != e {
+			t.Errorf("Expected key %q, got %q instead", e, k)
+		}
+	}
+}
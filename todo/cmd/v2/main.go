@@ -1,99 +1,7 @@
 package main
 
-import (
-	"bufio"
-	"flag"
-	"fmt"
-	"io"
-	"os"
-	"strings"
-	"todo"
-)
-
-// default file name
-var todoFileName = ".todo.json"
-
-const envKeyFilename = "TODO_FILENAME"
-
-func init() {
-	if os.Getenv(envKeyFilename) != "" {
-		todoFileName = os.Getenv(envKeyFilename)
-	}
-}
+import "todo/cmd/v2/cmd"
 
 func main() {
-	flag.Usage = func() {
-		fmt.Fprintf(flag.CommandLine.Output(),
-			"%s tool. Developed by @youngzy\n", os.Args[0])
-		fmt.Fprintf(flag.CommandLine.Output(), "Copyright 2022\n")
-		fmt.Fprintln(flag.CommandLine.Output(), "Usage information:")
-		flag.PrintDefaults()
-	}
-
-	add := flag.Bool("add", false, "Add task to the todo list")
-	list := flag.Bool("list", false, "List all tasks")
-	complete := flag.Int("complete", 0, "Item to be completed")
-	flag.Parse()
-
-	ls := &todo.List{}
-
-	if err := ls.Get(todoFileName); err != nil {
-		fmt.Fprintln(os.Stderr, err)
-		os.Exit(1)
-	}
-
-	// decide what to do based on the provided flags
-	switch {
-	case *list:
-		// list current to-do items
-		fmt.Print(ls)
-	case *complete > 0:
-		// complete the given item
-		if err := ls.Complete(*complete); err != nil {
-			fmt.Fprintln(os.Stderr, err)
-			os.Exit(1)
-		}
-
-		// save the new list
-		if err := ls.Save(todoFileName); err != nil {
-			fmt.Fprintln(os.Stderr, err)
-			os.Exit(1)
-		}
-	case *add:
-		// add the task
-		// flag.Args() returns all the remaining non-flag arguments
-		t, err := getTask(os.Stdin, flag.Args()...)
-		if err != nil {
-			fmt.Fprintln(os.Stderr, err)
-			os.Exit(1)
-		}
-		ls.Add(t)
-
-		// save the new list
-		if err := ls.Save(todoFileName); err != nil {
-			fmt.Fprintln(os.Stderr, err)
-			os.Exit(1)
-		}
-	default:
-		fmt.Fprintln(os.Stderr, "invalid option")
-		os.Exit(1)
-	}
-}
-
-// decide where to get the description for a new task
-// from arguments or STDIN
-func getTask(r io.Reader, args ...string) (string, error) {
-	if len(args) > 0 {
-		return strings.Join(args, " "), nil
-	}
-
-	s := bufio.NewScanner(r)
-	s.Scan()
-	if err := s.Err(); err != nil {
-		return "", err
-	}
-	if len(s.Text()) == 0 {
-		return "", fmt.Errorf("task cannot be blank")
-	}
-	return s.Text(), nil
+	cmd.Execute()
 }
@@ -1,13 +1,23 @@
 package main_test
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
-	"io"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"runtime"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
+	"time"
+	"todo"
+
+	"distributing/notify"
 )
 
 var (
@@ -32,52 +42,804 @@ func TestMain(m *testing.M) {
 
 	fmt.Println("Cleaning up...")
 	os.Remove(binName)
-	os.Remove(fileName)
 
 	os.Exit(result)
 }
 
-func TestTodoCLI(t *testing.T) {
-	task := "test task no.1"
+// cliEnv runs the tool on a list of its own, kept in
+// a temporary directory removed at the end of the test
+type cliEnv struct {
+	path string
+	// file is the list file the tool uses
+	file string
+}
 
+// newCLIEnv builds the environment the tool runs in during the test
+func newCLIEnv(t *testing.T) *cliEnv {
+	t.Helper()
 	dir, err := os.Getwd()
 	if err != nil {
 		t.Fatal(err)
 	}
-	cmdPath := filepath.Join(dir, binName)
 
-	t.Run("AddNewTaskFromArguments", func(t *testing.T) {
-		cmd := exec.Command(cmdPath, "-add", task)
-		if err := cmd.Run(); err != nil {
-			t.Fatal(err)
+	e := &cliEnv{
+		path: filepath.Join(dir, binName),
+		file: filepath.Join(t.TempDir(), fileName),
+	}
+	t.Setenv("TODO_FILENAME", e.file)
+	return e
+}
+
+// command returns the command running the tool with args
+func (e *cliEnv) command(args ...string) *exec.Cmd {
+	return exec.Command(e.path, args...)
+}
+
+// run runs the tool with args and returns its output,
+// failing the test when the tool fails
+func (e *cliEnv) run(t *testing.T, args ...string) string {
+	t.Helper()
+	return e.runInput(t, "", args...)
+}
+
+// runInput runs the tool like run, reading input from STDIN
+func (e *cliEnv) runInput(t *testing.T, input string, args ...string) string {
+	t.Helper()
+	cmd := e.command(args...)
+	cmd.Stdin = strings.NewReader(input)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("%v: %s: %s", args, err, out)
+	}
+	return string(out)
+}
+
+// fail runs the tool with args and returns its output,
+// failing the test when the tool doesn't fail
+func (e *cliEnv) fail(t *testing.T, args ...string) string {
+	t.Helper()
+	out, err := e.command(args...).CombinedOutput()
+	if err == nil {
+		t.Errorf("%v: expected an error, got %q", args, out)
+	}
+	return string(out)
+}
+
+// start runs the tool with args in the background until the end
+// of the test, and returns its output
+func (e *cliEnv) start(t *testing.T, args ...string) *syncBuffer {
+	t.Helper()
+	out := &syncBuffer{}
+	cmd := e.command(args...)
+	cmd.Stdout = out
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	})
+	return out
+}
+
+// syncBuffer is the output of a tool running in the background,
+// read by the test while the tool writes it
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// waitOutput waits until the output of a tool running in the
+// background is exp, failing the test after a generous deadline
+func waitOutput(t *testing.T, out *syncBuffer, exp string) {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for out.String() != exp {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected %q, got %q instead\n", exp, out.String())
 		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestTodoCLI(t *testing.T) {
+	e := newCLIEnv(t)
+	task := "test task no.1"
+
+	t.Run("AddNewTaskFromArguments", func(t *testing.T) {
+		e.run(t, "add", task)
 	})
 
 	task2 := "task 2"
 	t.Run("AddNewTaskFromSTDIN", func(t *testing.T) {
-		cmd := exec.Command(cmdPath, "-add")
-		cmdStdin, err := cmd.StdinPipe()
+		e.runInput(t, task2, "add")
+	})
+
+	t.Run("ListTasks", func(t *testing.T) {
+		expected := fmt.Sprintf("  1: %s\n  2: %s\n", task, task2)
+		if out := e.run(t, "list"); expected != out {
+			t.Errorf("Expected %q, got %q instead\n", expected, out)
+		}
+	})
+
+	t.Run("DeleteTask", func(t *testing.T) {
+		e.run(t, "del", "1")
+	})
+
+	t.Run("CompleteTaskByID", func(t *testing.T) {
+		e.run(t, "complete", "2")
+	})
+
+	t.Run("ListTasksAfterDelete", func(t *testing.T) {
+		expected := fmt.Sprintf("X 2: %s\n", task2)
+		if out := e.run(t, "list"); expected != out {
+			t.Errorf("Expected %q, got %q instead\n", expected, out)
+		}
+	})
+
+	task3 := "task 3"
+	t.Run("AddTaskWithDetails", func(t *testing.T) {
+		e.run(t, "add", "--priority", "B", "--due", "2026-11-01", "--tags", "work,home", task3)
+
+		expected := fmt.Sprintf("X 2: %s\n  3: %s (B) due:2026-11-01 +home +work\n",
+			task2, task3)
+		if out := e.run(t, "list"); expected != out {
+			t.Errorf("Expected %q, got %q instead\n", expected, out)
+		}
+	})
+
+	t.Run("ListTasksWithQuery", func(t *testing.T) {
+		expected := fmt.Sprintf("  3: %s (B) due:2026-11-01 +home +work\n", task3)
+		if out := e.run(t, "list", "done:false", "tag:work", "due<2026-12-01"); expected != out {
+			t.Errorf("Expected %q, got %q instead\n", expected, out)
+		}
+	})
+
+	t.Run("AddTasksConcurrently", func(t *testing.T) {
+		cmds := make([]*exec.Cmd, 5)
+		for i := range cmds {
+			cmds[i] = e.command("add", "--tags", "batch", fmt.Sprintf("concurrent task %d", i))
+			if err := cmds[i].Start(); err != nil {
+				t.Fatal(err)
+			}
+		}
+		for _, cmd := range cmds {
+			if err := cmd.Wait(); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		out := e.run(t, "list", "tag:batch")
+		if lines := strings.Count(out, "\n"); lines != len(cmds) {
+			t.Errorf("Expected %d tasks, got %d instead:\n%s", len(cmds), lines, out)
+		}
+	})
+
+	t.Run("AddTaskInvalidDue", func(t *testing.T) {
+		e.fail(t, "add", "--due", "tomorrow", "task 4")
+	})
+}
+
+func TestTodoCLI_SQLite(t *testing.T) {
+	e := newCLIEnv(t)
+	t.Setenv("TODO_STORAGE", "sqlite")
+	t.Setenv("TODO_FILENAME", filepath.Join(filepath.Dir(e.file), "todo.db"))
+
+	e.run(t, "add", "sqlite task 1")
+	e.run(t, "add", "sqlite task 2")
+	e.run(t, "complete", "1")
+
+	expected := "X 1: sqlite task 1\n  2: sqlite task 2\n"
+	if out := e.run(t, "list"); expected != out {
+		t.Errorf("Expected %q, got %q instead\n", expected, out)
+	}
+}
+
+func TestTodoCLI_UndoRedo(t *testing.T) {
+	e := newCLIEnv(t)
+
+	e.run(t, "add", "task 1")
+	e.run(t, "add", "task 2")
+	e.run(t, "del", "1")
+
+	if out := e.run(t, "undo"); !strings.HasPrefix(out, "Undid 3 ") {
+		t.Errorf("Expected undo of change 3, got %q instead", out)
+	}
+	if out, exp := e.run(t, "list"), "  1: task 1\n  2: task 2\n"; out != exp {
+		t.Errorf("Expected %q, got %q instead", exp, out)
+	}
+
+	e.run(t, "redo")
+	if out, exp := e.run(t, "list"), "  2: task 2\n"; out != exp {
+		t.Errorf("Expected %q, got %q instead", exp, out)
+	}
+
+	history := strings.Split(strings.TrimSpace(e.run(t, "history")), "\n")
+	if len(history) != 5 {
+		t.Fatalf("Expected 5 history entries, got %d instead: %q", len(history), history)
+	}
+	if !strings.Contains(history[4], "redo 3") {
+		t.Errorf("Expected last entry to redo 3, got %q instead", history[4])
+	}
+
+	e.fail(t, "redo")
+}
+
+func TestTodoCLI_EditReopenMove(t *testing.T) {
+	e := newCLIEnv(t)
+	for _, args := range [][]string{
+		{"add", "task 1"},
+		{"add", "tsak 2"},
+		{"add", "task 3"},
+		{"complete", "1"},
+		{"edit", "2", "task", "2"},
+		{"reopen", "1"},
+		{"move", "3", "--to", "1"},
+	} {
+		e.run(t, args...)
+	}
+
+	expected := "  3: task 3\n  1: task 1\n  2: task 2\n"
+	if out := e.run(t, "list"); expected != out {
+		t.Errorf("Expected %q, got %q instead\n", expected, out)
+	}
+}
+
+func TestTodoCLI_ImportExport(t *testing.T) {
+	e := newCLIEnv(t)
+
+	importFile := filepath.Join(filepath.Dir(e.file), "tasks.md")
+	data := "# Tasks\n- [ ] task 1 +work\n- [x] task 2\n"
+	if err := os.WriteFile(importFile, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if expected, out := "Imported 2 items\n", e.run(t, "import", importFile); expected != out {
+		t.Errorf("Expected %q, got %q instead\n", expected, out)
+	}
+	if expected, out := "- [ ] task 1 +work\n- [x] task 2\n", e.run(t, "export", "markdown"); expected != out {
+		t.Errorf("Expected %q, got %q instead\n", expected, out)
+	}
+}
+
+func TestTodoCLI_BulkAndNotes(t *testing.T) {
+	e := newCLIEnv(t)
+
+	t.Run("AddBulk", func(t *testing.T) {
+		e.runInput(t, "bulk task 1\n\n  \nbulk task 2\nbulk task 3\n", "add", "--bulk", "--tags", "bulk")
+
+		expected := "  1: bulk task 1 +bulk\n  2: bulk task 2 +bulk\n  3: bulk task 3 +bulk\n"
+		if out := e.run(t, "list"); expected != out {
+			t.Errorf("Expected %q, got %q instead\n", expected, out)
+		}
+	})
+
+	t.Run("AddWithNotes", func(t *testing.T) {
+		e.runInput(t, "task with notes\n\nfirst line\nsecond line\n", "add", "--priority", "A")
+
+		out := e.run(t, "show", "4")
+		expLines := []string{
+			"ID:         4\n",
+			"Task:       task with notes\n",
+			"Completed:  No\n",
+			"Priority:   A\n",
+			"\nfirst line\nsecond line\n",
+		}
+		for _, exp := range expLines {
+			if !strings.Contains(out, exp) {
+				t.Errorf("Expected %q in output %q", exp, out)
+			}
+		}
+	})
+
+	t.Run("ShowNotFound", func(t *testing.T) {
+		e.fail(t, "show", "5")
+	})
+}
+
+func TestTodoCLI_Recurring(t *testing.T) {
+	e := newCLIEnv(t)
+	e.run(t, "add", "--due", "2026-11-02", "--recur", "weekly", "weekly report")
+	e.run(t, "complete", "1")
+
+	// the next occurrence is a week later, or after today
+	// when the first one is already in the past
+	lines := strings.Split(e.run(t, "list"), "\n")
+	if exp := "X 1: weekly report due:2026-11-02 rec:weekly:mon"; lines[0] != exp {
+		t.Errorf("Expected %q, got %q instead\n", exp, lines[0])
+	}
+	if !strings.HasPrefix(lines[1], "  2: weekly report due:") ||
+		!strings.HasSuffix(lines[1], " rec:weekly:mon") {
+		t.Errorf("Expected next occurrence, got %q instead\n", lines[1])
+	}
+
+	e.fail(t, "add", "--recur", "yearly", "yearly task")
+}
+
+func TestTodoCLI_SubtasksAndBlockers(t *testing.T) {
+	e := newCLIEnv(t)
+	for _, args := range [][]string{
+		{"add", "release"},
+		{"add", "--parent", "1", "write docs"},
+		{"add", "--parent", "1", "tag version"},
+		{"block", "3", "--by", "2"},
+	} {
+		e.run(t, args...)
+	}
+
+	e.fail(t, "complete", "3")
+
+	e.run(t, "unblock", "3", "--by", "2")
+	e.run(t, "complete", "3")
+
+	expected := "  1: release\n    2: write docs\nX   3: tag version\n"
+	if out := e.run(t, "list"); expected != out {
+		t.Errorf("Expected %q, got %q instead\n", expected, out)
+	}
+}
+
+func TestTodoCLI_LinksAcrossLists(t *testing.T) {
+	e := newCLIEnv(t)
+	e.run(t, "add", "buy milk")
+	e.run(t, "--list-name", "work", "add", "write report")
+
+	e.fail(t, "add", "--parent", "2", "buy bread")
+	e.fail(t, "block", "1", "--by", "2")
+	e.run(t, "--list-name", "work", "add", "--parent", "2", "outline report")
+
+	if out, exp := e.run(t, "list"), "  1: buy milk\n"; out != exp {
+		t.Errorf("Expected %q, got %q instead\n", exp, out)
+	}
+	if out, exp := e.run(t, "--list-name", "work", "list"), "  2: write report\n    3: outline report\n"; out != exp {
+		t.Errorf("Expected %q, got %q instead\n", exp, out)
+	}
+}
+
+func TestTodoCLI_NamedLists(t *testing.T) {
+	e := newCLIEnv(t)
+	for _, args := range [][]string{
+		{"add", "buy milk"},
+		{"--list-name", "work", "add", "write report"},
+		{"add", "prepare slides"},
+		{"move", "3", "--to-list", "work"},
+		{"--list-name", "work", "complete", "2"},
+	} {
+		e.run(t, args...)
+	}
+
+	testCases := []struct {
+		name string
+		args []string
+		exp  string
+	}{
+		{"Default", []string{"list"}, "  1: buy milk\n"},
+		{"Named", []string{"--list-name", "work", "list"}, "X 2: write report\n  3: prepare slides\n"},
+		{"Lists", []string{"lists"}, "default  1 items  0 done\nwork     2 items  1 done\n"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if out := e.run(t, tc.args...); tc.exp != out {
+				t.Errorf("Expected %q, got %q instead\n", tc.exp, out)
+			}
+		})
+	}
+}
+
+func TestTodoCLI_Search(t *testing.T) {
+	e := newCLIEnv(t)
+	e.run(t, "add", "write weekly report")
+	e.run(t, "add", "review reports")
+	e.run(t, "add", "call mom")
+
+	testCases := []struct {
+		name string
+		args []string
+		exp  string
+	}{
+		{"Substring", []string{"search", "Report"}, "  1: write weekly report\n  2: review reports\n"},
+		{"Word", []string{"search", "report", "--word"}, "  1: write weekly report\n"},
+		{"Regexp", []string{"search", "^(call|review) ", "--regexp"}, "  2: review reports\n  3: call mom\n"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if out := e.run(t, tc.args...); tc.exp != out {
+				t.Errorf("Expected %q, got %q instead\n", tc.exp, out)
+			}
+		})
+	}
+}
+
+func TestTodoCLI_ArchivePurge(t *testing.T) {
+	e := newCLIEnv(t)
+	for _, task := range []string{"task 1", "task 2", "task 3", "task 4"} {
+		e.run(t, "add", task)
+	}
+	e.run(t, "complete", "1")
+	e.run(t, "complete", "3")
+
+	// nothing was completed more than a day ago
+	if out := e.run(t, "archive", "--days", "1"); out != "Archived 0 items\n" {
+		t.Errorf("Expected no items archived, got %q", out)
+	}
+	if out := e.run(t, "archive"); out != "Archived 2 items\n" {
+		t.Errorf("Expected 2 items archived, got %q", out)
+	}
+	if out, exp := e.run(t, "archived"), "X 1: task 1\nX 3: task 3\n"; out != exp {
+		t.Errorf("Expected %q, got %q instead\n", exp, out)
+	}
+
+	e.run(t, "complete", "4")
+	if out := e.run(t, "purge"); out != "Purged 1 items\n" {
+		t.Errorf("Expected 1 item purged, got %q", out)
+	}
+	if out, exp := e.run(t, "list"), "  2: task 2\n"; out != exp {
+		t.Errorf("Expected %q, got %q instead\n", exp, out)
+	}
+
+	// archiving again adds to the archive, without reusing IDs
+	e.run(t, "add", "task 5")
+	e.run(t, "complete", "5")
+	if out := e.run(t, "archive"); out != "Archived 1 items\n" {
+		t.Errorf("Expected 1 item archived, got %q", out)
+	}
+	if out, exp := e.run(t, "archived"), "X 1: task 1\nX 3: task 3\nX 5: task 5\n"; out != exp {
+		t.Errorf("Expected %q, got %q instead\n", exp, out)
+	}
+}
+
+func TestTodoCLI_Stats(t *testing.T) {
+	e := newCLIEnv(t)
+	for _, args := range [][]string{
+		{"add", "task 1"},
+		{"add", "task 2"},
+		{"add", "task 3"},
+		{"complete", "2"},
+	} {
+		e.run(t, args...)
+	}
+
+	t.Run("Text", func(t *testing.T) {
+		out := e.run(t, "stats")
+		for _, exp := range []string{"Open:  ", "Done:  ", "Completed per day:", "  1: task 1"} {
+			if !strings.Contains(out, exp) {
+				t.Errorf("Expected %q in:\n%s", exp, out)
+			}
+		}
+	})
+
+	t.Run("JSON", func(t *testing.T) {
+		out := e.run(t, "stats", "--json")
+		var s struct {
+			Open            int `json:"open"`
+			Done            int `json:"done"`
+			CompletedPerDay []struct {
+				Count int `json:"count"`
+			} `json:"completedPerDay"`
+		}
+		if err := json.Unmarshal([]byte(out), &s); err != nil {
+			t.Fatal(err)
+		}
+		if s.Open != 2 || s.Done != 1 || s.CompletedPerDay[len(s.CompletedPerDay)-1].Count != 1 {
+			t.Errorf("Unexpected statistics %s", out)
+		}
+	})
+}
+
+func TestTodoCLI_Encrypted(t *testing.T) {
+	e := newCLIEnv(t)
+
+	// a plain list is encrypted with its journal
+	e.run(t, "add", "call ACME Corp")
+	t.Setenv("TODO_PASSPHRASE", "correct horse")
+	e.run(t, "encrypt")
+	e.run(t, "add", "send ACME Corp the contract")
+
+	for _, f := range []string{e.file, e.file + ".journal"} {
+		data, err := os.ReadFile(f)
 		if err != nil {
 			t.Fatal(err)
 		}
-		io.WriteString(cmdStdin, task2)
-		cmdStdin.Close()
+		if strings.Contains(string(data), "ACME") {
+			t.Errorf("Expected %s to be encrypted", f)
+		}
+	}
+
+	if exp, out := "  1: call ACME Corp\n  2: send ACME Corp the contract\n", e.run(t, "list"); out != exp {
+		t.Errorf("Expected %q, got %q instead\n", exp, out)
+	}
+
+	t.Setenv("TODO_PASSPHRASE", "battery staple")
+	if out := e.fail(t, "list"); !strings.Contains(out, "wrong passphrase") {
+		t.Errorf("Expected wrong passphrase error, got %q", out)
+	}
+}
+
+func TestTodoCLI_Format(t *testing.T) {
+	e := newCLIEnv(t)
+	e.run(t, "add", "--tags", "work", "write report")
+	e.run(t, "add", "buy milk")
+	e.run(t, "complete", "2")
+
+	testCases := []struct {
+		name string
+		args []string
+		exp  string
+	}{
+		{"Plain", []string{"list", "--format", "plain"}, "  1: write report +work\nX 2: buy milk\n"},
+		{"Template", []string{"list", "--format", "template:{{.ID}}|{{.Done}}|{{.Task}}", "done:true"}, "2|true|buy milk\n"},
+		{"SearchTemplate", []string{"search", "report", "--format", "template:{{.Task}}"}, "write report\n"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if out := e.run(t, tc.args...); tc.exp != out {
+				t.Errorf("Expected %q, got %q instead\n", tc.exp, out)
+			}
+		})
+	}
+
+	t.Run("Table", func(t *testing.T) {
+		out := e.run(t, "list", "--format", "table")
+		lines := strings.Split(out, "\n")
+		if !strings.HasPrefix(lines[0], "ID  DONE  TASK") || !strings.HasPrefix(lines[2], "2   X     buy milk") {
+			t.Errorf("Unexpected table:\n%s", out)
+		}
+	})
 
-		if err := cmd.Run(); err != nil {
+	t.Run("JSON", func(t *testing.T) {
+		out := e.run(t, "list", "--format", "json")
+		var items []struct {
+			ID   int
+			Task string
+		}
+		if err := json.Unmarshal([]byte(out), &items); err != nil {
 			t.Fatal(err)
 		}
+		if len(items) != 2 || items[1].Task != "buy milk" {
+			t.Errorf("Unexpected items %s", out)
+		}
 	})
 
-	t.Run("ListTasks", func(t *testing.T) {
-		cmd := exec.Command(cmdPath, "-list")
-		out, err := cmd.CombinedOutput()
+	e.fail(t, "list", "--format", "yaml")
+}
+
+func TestTodoCLI_RemindDryRun(t *testing.T) {
+	e := newCLIEnv(t)
+	soon := time.Now().Add(5 * time.Minute).Format(todo.DueTimeFormat)
+	later := time.Now().Add(5 * time.Hour).Format(todo.DueTimeFormat)
+	for _, args := range [][]string{
+		{"add", "--due", soon, "submit report"},
+		{"add", "--due", "2026-01-02", "pay rent"},
+		{"add", "--due", later, "book flights"},
+		{"add", "buy milk"},
+	} {
+		e.run(t, args...)
+	}
+
+	// the daemon keeps running until stopped, checking the list
+	// several times without sending the same reminder twice
+	out := e.start(t, "remind", "--dry-run", "--interval", "10ms")
+	exp := fmt.Sprintf("[%s] Todo reminder: 1: submit report is due %s\n"+
+		"[%s] Todo reminder: 2: pay rent is overdue since 2026-01-02\n",
+		notify.Severity(notify.SeverityLow), soon, notify.Severity(notify.SeverityUrgent))
+	waitOutput(t, out, exp)
+
+	// a slow run checks the list fewer times, but can't fail
+	time.Sleep(100 * time.Millisecond)
+	if exp != out.String() {
+		t.Errorf("Expected %q, got %q instead\n", exp, out.String())
+	}
+}
+
+func TestTodoCLI_RemindSQLite(t *testing.T) {
+	e := newCLIEnv(t)
+	t.Setenv("TODO_STORAGE", "sqlite")
+	t.Setenv("TODO_FILENAME", filepath.Join(filepath.Dir(e.file), "todo.db"))
+	soon := time.Now().Add(5 * time.Minute).Format(todo.DueTimeFormat)
+	e.run(t, "add", "--due", soon, "buy milk")
+
+	// the first reminder tells the daemon has read the list
+	out := e.start(t, "remind", "--dry-run", "--interval", "10ms")
+	exp := fmt.Sprintf("[%s] Todo reminder: 1: buy milk is due %s\n",
+		notify.Severity(notify.SeverityLow), soon)
+	waitOutput(t, out, exp)
+
+	// the daemon notices the items added while it's running
+	e.run(t, "add", "--due", soon, "submit report")
+	exp += fmt.Sprintf("[%s] Todo reminder: 2: submit report is due %s\n",
+		notify.Severity(notify.SeverityLow), soon)
+	waitOutput(t, out, exp)
+}
+
+func TestTodoCLI_Completion(t *testing.T) {
+	e := newCLIEnv(t)
+	for _, args := range [][]string{
+		{"add", "buy milk"},
+		{"add", "call mom"},
+		{"add", "--list-name", "work", "write report"},
+		{"complete", "1"},
+	} {
+		e.run(t, args...)
+	}
+
+	// the shell scripts call the hidden __complete command,
+	// which prints the completions and then the directive
+	testCases := []struct {
+		name string
+		args []string
+		exp  string
+	}{
+		{"Show", []string{"show", ""}, "1\tbuy milk\n2\tcall mom\n:4\n"},
+		{"Complete", []string{"complete", ""}, "2\tcall mom\n:4\n"},
+		{"Reopen", []string{"reopen", ""}, "1\tbuy milk\n:4\n"},
+		{"NamedList", []string{"show", "--list-name", "work", ""}, "3\twrite report\n:4\n"},
+		{"ListNames", []string{"move", "2", "--to-list", ""}, "default\t2 items\nwork\t1 items\n:4\n"},
+		{"FlagAfterArgs", []string{"block", "2", "--by", ""}, "1\tbuy milk\n2\tcall mom\n:4\n"},
+		{"FlagAfterComma", []string{"block", "2", "--by", "1,"}, "1,1\tbuy milk\n1,2\tcall mom\n:4\n"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			out, err := e.command(append([]string{"__complete"}, tc.args...)...).Output()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tc.exp != string(out) {
+				t.Errorf("Expected %q, got %q instead\n", tc.exp, string(out))
+			}
+		})
+	}
+
+	// completing doesn't create the list
+	t.Run("NoList", func(t *testing.T) {
+		t.Setenv("TODO_STORAGE", "sqlite")
+		db := filepath.Join(filepath.Dir(e.file), "todo.db")
+		t.Setenv("TODO_FILENAME", db)
+
+		out, err := e.command("__complete", "show", "").Output()
 		if err != nil {
 			t.Fatal(err)
 		}
-
-		expected := fmt.Sprintf("  1: %s\n  2: %s\n", task, task2)
-		if expected != string(out) {
-			t.Errorf("Expected %q, got %q instead\n", expected, string(out))
+		if exp := ":4\n"; exp != string(out) {
+			t.Errorf("Expected %q, got %q instead\n", exp, string(out))
+		}
+		if _, err := os.Stat(db); !os.IsNotExist(err) {
+			t.Errorf("Expected no database created, got %v", err)
 		}
 	})
+
+	for _, shell := range []string{"bash", "zsh", "fish"} {
+		t.Run(shell, func(t *testing.T) {
+			if out := e.run(t, "completion", shell); !strings.Contains(out, "__complete") {
+				t.Errorf("Expected a %s completion script, got:\n%s", shell, out)
+			}
+		})
+	}
+}
+
+// newTodoServer returns a test server with the todoServer
+// API for the default list, kept in ls. The server holds mu
+// while it uses ls, so the test must hold it to change ls
+func newTodoServer(ls *todo.List, mu *sync.Mutex) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if r.URL.Path == "/todo" {
+			switch r.Method {
+			case http.MethodGet:
+				json.NewEncoder(w).Encode(map[string]todo.List{"results": *ls})
+			case http.MethodPost:
+				var item struct {
+					Task string `json:"task"`
+				}
+				if err := json.NewDecoder(r.Body).Decode(&item); err != nil {
+					http.Error(w, err.Error(), http.StatusBadRequest)
+					return
+				}
+				id := ls.Add(item.Task)
+				w.Header().Set("Location", fmt.Sprintf("/todo/%d", id))
+				w.WriteHeader(http.StatusCreated)
+			default:
+				http.Error(w, "Method not supported", http.StatusMethodNotAllowed)
+			}
+			return
+		}
+
+		id, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/todo/"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		i, err := ls.IndexOf(id)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+
+		q := r.URL.Query()
+		_, complete := q["complete"]
+		_, reopen := q["reopen"]
+		switch {
+		case r.Method == http.MethodGet:
+			json.NewEncoder(w).Encode(map[string]todo.List{"results": {Items: ls.Items[i : i+1]}})
+			return
+		case r.Method == http.MethodDelete:
+			err = ls.Delete(id)
+		case r.Method == http.MethodPatch && complete:
+			err = ls.Complete(id)
+		case r.Method == http.MethodPatch && reopen:
+			err = ls.Reopen(id)
+		case r.Method == http.MethodPatch:
+			var item struct {
+				Task string `json:"task"`
+			}
+			if err = json.NewDecoder(r.Body).Decode(&item); err == nil {
+				err = ls.Edit(id, item.Task)
+			}
+		default:
+			http.Error(w, "Method not supported", http.StatusMethodNotAllowed)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+}
+
+func TestTodoCLI_Sync(t *testing.T) {
+	e := newCLIEnv(t)
+
+	var mu sync.Mutex
+	remote := &todo.List{}
+	remote.Add("call mom")
+	remote.Add("write report")
+	ts := newTodoServer(remote, &mu)
+	defer ts.Close()
+
+	e.run(t, "add", "buy milk")
+	e.run(t, "add", "call mom")
+	if out, exp := e.run(t, "sync", ts.URL), "Sent 1 changes, received 1 changes\n"; out != exp {
+		t.Errorf("Expected %q, got %q instead", exp, out)
+	}
+	if out, exp := e.run(t, "list"), "  1: buy milk\n  2: call mom\n  3: write report\n"; out != exp {
+		t.Errorf("Expected %q, got %q instead", exp, out)
+	}
+
+	// changed on both sides since the last sync
+	e.run(t, "complete", "1")
+	e.run(t, "edit", "2", "call dad")
+	mu.Lock()
+	remote.Edit(1, "call mom and dad")
+	remote.Delete(2)
+	mu.Unlock()
+
+	exp := "Sent 1 changes, received 2 changes\n" +
+		"Conflict: 2: call dad: edited on both sides, replaced by \"call mom and dad\" from the server\n"
+	if out := e.run(t, "sync", ts.URL); out != exp {
+		t.Errorf("Expected %q, got %q instead", exp, out)
+	}
+	if out, exp := e.run(t, "list"), "X 1: buy milk\n  2: call mom and dad\n"; out != exp {
+		t.Errorf("Expected %q, got %q instead", exp, out)
+	}
+	mu.Lock()
+	got := remote.String()
+	mu.Unlock()
+	if exp := "  1: call mom and dad\nX 3: buy milk\n"; got != exp {
+		t.Errorf("Expected server list %q, got %q instead", exp, got)
+	}
+
+	e.fail(t, "sync", "http://127.0.0.1:1")
 }
@@ -0,0 +1,210 @@
+package todo
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+var (
+	ErrPassphraseRequired = errors.New("encrypted file: passphrase required")
+	ErrWrongPassphrase    = errors.New("wrong passphrase")
+	ErrTampered           = errors.New("encrypted data was tampered with or is corrupted")
+)
+
+// Encrypted files start with encryptedMagic, followed by the number of
+// key derivation iterations, the salt, a value to check the key and
+// the AES-GCM nonce. The whole header is authenticated with the data
+const (
+	encryptedMagic = "TODOENC1"
+	kdfIterations  = 100000
+	saltSize       = 16
+	checkSize      = 16
+	keySize        = 32
+	headerSize     = len(encryptedMagic) + 4 + saltSize + checkSize
+)
+
+// Key encrypts data with a passphrase, and decrypts the data
+// encrypted with it. It keeps the salt used to encrypt and a cache
+// of the keys derived from the passphrase, and is safe for
+// concurrent use. A nil Key doesn't encrypt
+type Key struct {
+	mu         sync.Mutex
+	passphrase string
+	salt       []byte
+	keys       map[string][]byte
+}
+
+// NewKey returns the key for passphrase,
+// nil for an empty passphrase
+func NewKey(passphrase string) *Key {
+	if passphrase == "" {
+		return nil
+	}
+	return &Key{passphrase: passphrase, keys: map[string][]byte{}}
+}
+
+// Option is a setting of a storage, a journal or sync states
+type Option func(*options)
+
+type options struct {
+	key *Key
+}
+
+// WithKey encrypts the saved data with key, and decrypts the
+// encrypted data read back. Data without encryption is still read
+// as it is. A nil key turns encryption off
+func WithKey(key *Key) Option {
+	return func(o *options) {
+		o.key = key
+	}
+}
+
+func newOptions(opts []Option) options {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// IsEncrypted reports whether data was encrypted with a passphrase
+func IsEncrypted(data []byte) bool {
+	return bytes.HasPrefix(data, []byte(encryptedMagic))
+}
+
+// IsEncryptedFile reports whether the file with the given name is
+// encrypted. Missing files aren't
+func IsEncryptedFile(filename string) (bool, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return false, nil
+		}
+		return false, err
+	}
+	defer f.Close()
+
+	magic := make([]byte, len(encryptedMagic))
+	if _, err := io.ReadFull(f, magic); err != nil {
+		return false, nil
+	}
+	return IsEncrypted(magic), nil
+}
+
+// encrypt encrypts data with AES-256-GCM using a key
+// derived from the passphrase
+func (k *Key) encrypt(data []byte) ([]byte, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if k.salt == nil {
+		k.salt = make([]byte, saltSize)
+		if _, err := rand.Read(k.salt); err != nil {
+			return nil, err
+		}
+	}
+	key := k.derived(k.salt, kdfIterations)
+
+	header := make([]byte, headerSize)
+	p := copy(header, encryptedMagic)
+	binary.BigEndian.PutUint32(header[p:], kdfIterations)
+	p += 4
+	p += copy(header[p:], k.salt)
+	copy(header[p:], keyCheck(key))
+
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	out := append(header, nonce...)
+	return gcm.Seal(out, nonce, data, header), nil
+}
+
+// decrypt decrypts data encrypted by encrypt, telling
+// a wrong passphrase apart from changed data
+func (k *Key) decrypt(data []byte) ([]byte, error) {
+	if k == nil {
+		return nil, ErrPassphraseRequired
+	}
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if !IsEncrypted(data) || len(data) < headerSize {
+		return nil, ErrTampered
+	}
+
+	header := data[:headerSize]
+	p := len(encryptedMagic)
+	iterations := int(binary.BigEndian.Uint32(header[p:]))
+	p += 4
+	salt := header[p : p+saltSize]
+	p += saltSize
+	check := header[p : p+checkSize]
+
+	if iterations < 1 || iterations > 10*kdfIterations {
+		return nil, ErrTampered
+	}
+	key := k.derived(salt, iterations)
+	if !hmac.Equal(check, keyCheck(key)) {
+		return nil, ErrWrongPassphrase
+	}
+
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	rest := data[headerSize:]
+	if len(rest) < gcm.NonceSize() {
+		return nil, ErrTampered
+	}
+	plain, err := gcm.Open(nil, rest[:gcm.NonceSize()], rest[gcm.NonceSize():], header)
+	if err != nil {
+		return nil, ErrTampered
+	}
+	return plain, nil
+}
+
+// derived returns the key for the salt, deriving it from the
+// passphrase the first time. The caller must hold the lock
+func (k *Key) derived(salt []byte, iterations int) []byte {
+	id := fmt.Sprintf("%x:%d", salt, iterations)
+	if key, ok := k.keys[id]; ok {
+		return key
+	}
+	key := pbkdf2.Key([]byte(k.passphrase), salt, iterations, keySize, sha256.New)
+	k.keys[id] = key
+	return key
+}
+
+// keyCheck returns a value derived from key that's kept with the
+// data to report a wrong passphrase without trying to decrypt
+func keyCheck(key []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte("todo key check"))
+	return mac.Sum(nil)[:checkSize]
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,170 @@
+package todo_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"todo"
+)
+
+func TestStorage_Encrypted(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, ".todo.json")
+
+	ls := todo.List{}
+	ls.Add("Call ACME Corp about the renewal")
+
+	store, err := todo.NewStorage(todo.StorageJSON, filename, todo.WithKey(todo.NewKey("correct horse")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Save(&ls); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(data, []byte("ACME")) {
+		t.Fatal("Expected the task to be encrypted")
+	}
+	if enc, err := todo.IsEncryptedFile(filename); err != nil || !enc {
+		t.Errorf("Expected encrypted file, got %t, %v", enc, err)
+	}
+
+	// a new key for the same passphrase decrypts the list
+	store, err = todo.NewStorage(todo.StorageJSON, filename, todo.WithKey(todo.NewKey("correct horse")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := todo.List{}
+	if err := store.Get(&got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Items) != 1 || got.Items[0].Task != ls.Items[0].Task {
+		t.Errorf("Expected %q, got:\n%s", ls.Items[0].Task, got.String())
+	}
+
+	testCases := []struct {
+		name       string
+		passphrase string
+		tamper     bool
+		expErr     error
+	}{
+		{name: "NoPassphrase", passphrase: "", expErr: todo.ErrPassphraseRequired},
+		{name: "WrongPassphrase", passphrase: "battery staple", expErr: todo.ErrWrongPassphrase},
+		{name: "Tampered", passphrase: "correct horse", tamper: true, expErr: todo.ErrTampered},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			f := filename
+			if tc.tamper {
+				changed := append([]byte(nil), data...)
+				changed[len(changed)-1] ^= 1
+				f = filepath.Join(dir, "tampered.json")
+				if err := os.WriteFile(f, changed, 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			store, err := todo.NewStorage(todo.StorageJSON, f, todo.WithKey(todo.NewKey(tc.passphrase)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = store.Get(&todo.List{})
+			if !errors.Is(err, tc.expErr) {
+				t.Errorf("Expected %v, got %v instead.", tc.expErr, err)
+			}
+		})
+	}
+}
+
+func TestJournal_Encrypted(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), ".todo.json.journal")
+	j := todo.NewJournal(filename)
+
+	before := todo.List{}
+	after := before.Clone()
+	after.Add("Call ACME Corp")
+	if err := j.Record("add", before, after); err != nil {
+		t.Fatal(err)
+	}
+
+	// entries recorded before setting the key are
+	// encrypted when rewriting the journal
+	j = todo.NewJournal(filename, todo.WithKey(todo.NewKey("correct horse")))
+	before = after.Clone()
+	after.Add("Send ACME Corp the contract")
+	if err := j.Record("add", before, after); err != nil {
+		t.Fatal(err)
+	}
+	if err := j.Rewrite(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(data, []byte("ACME")) {
+		t.Fatal("Expected the journal entry to be encrypted")
+	}
+
+	entries, err := j.Entries()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || entries[0].Changes[0].After.Task != "Call ACME Corp" {
+		t.Errorf("Unexpected entries %v", entries)
+	}
+
+	if _, err := todo.NewJournal(filename).Entries(); !errors.Is(err, todo.ErrPassphraseRequired) {
+		t.Errorf("Expected ErrPassphraseRequired, got %v instead.", err)
+	}
+}
+
+func TestKey_Concurrent(t *testing.T) {
+	dir := t.TempDir()
+	key := todo.WithKey(todo.NewKey("correct horse"))
+
+	// storages sharing a key, and others with their own key
+	errs := make(chan error)
+	for i := 0; i < 8; i++ {
+		opt := key
+		if i%2 == 1 {
+			opt = todo.WithKey(todo.NewKey(fmt.Sprintf("passphrase %d", i)))
+		}
+		go func(i int, opt todo.Option) {
+			store, err := todo.NewStorage(todo.StorageJSON, filepath.Join(dir, fmt.Sprintf("todo%d.json", i)), opt)
+			if err != nil {
+				errs <- err
+				return
+			}
+			ls := todo.List{}
+			ls.Add(fmt.Sprintf("Task %d", i))
+			if err := store.Save(&ls); err != nil {
+				errs <- err
+				return
+			}
+			got := todo.List{}
+			if err := store.Get(&got); err != nil {
+				errs <- err
+				return
+			}
+			if got.String() != ls.String() {
+				errs <- fmt.Errorf("expected %q, got %q instead", ls.String(), got.String())
+				return
+			}
+			errs <- nil
+		}(i, opt)
+	}
+	for i := 0; i < 8; i++ {
+		if err := <-errs; err != nil {
+			t.Error(err)
+		}
+	}
+}
@@ -0,0 +1,512 @@
+package todo
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Formats for importing and exporting lists
+const (
+	FormatTodoTxt  = "todotxt"
+	FormatCSV      = "csv"
+	FormatMarkdown = "markdown"
+)
+
+var (
+	csvHeader = []string{"id", "task", "done", "created", "completed",
+		"priority", "due", "tags", "notes", "recur"}
+	markdownItem = regexp.MustCompile(`^\s*[-*+]\s+\[([ xX])\]\s+(.*)$`)
+	priorityWord = regexp.MustCompile(`^\([A-Z]\)$`)
+)
+
+// FormatFromFilename guesses the format of a file from its extension:
+// .csv for CSV, .md for Markdown and anything else for todo.txt
+func FormatFromFilename(filename string) string {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".csv":
+		return FormatCSV
+	case ".md", ".markdown":
+		return FormatMarkdown
+	}
+	return FormatTodoTxt
+}
+
+// Export writes the list to w in the given format
+func (list *List) Export(w io.Writer, format string) error {
+	switch format {
+	case FormatTodoTxt:
+		return list.exportTodoTxt(w)
+	case FormatCSV:
+		return list.exportCSV(w)
+	case FormatMarkdown:
+		return list.exportMarkdown(w)
+	}
+	return unknownFormat(format)
+}
+
+// Import reads items in the given format from r and appends them
+// to the list with new IDs. It returns the number of items added
+func (list *List) Import(r io.Reader, format string) (int, error) {
+	var (
+		items []item
+		err   error
+	)
+	switch format {
+	case FormatTodoTxt:
+		items, err = importLines(r, parseTodoTxt)
+	case FormatCSV:
+		items, err = importCSV(r)
+	case FormatMarkdown:
+		items, err = importMarkdown(r)
+	default:
+		return 0, unknownFormat(format)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+	for _, v := range items {
+		if v.CreateAt.IsZero() {
+			v.CreateAt = now
+		}
+		if v.Done && v.CompletedAt.IsZero() {
+			v.CompletedAt = now
+		}
+
+		tags := v.Tags
+		v.Tags = nil
+		v.ID = list.nextID()
+		list.Items = append(list.Items, v)
+		list.AddTags(v.ID, tags...)
+	}
+	return len(items), nil
+}
+
+func unknownFormat(format string) error {
+	return fmt.Errorf("unknown format %q: must be %s, %s or %s",
+		format, FormatTodoTxt, FormatCSV, FormatMarkdown)
+}
+
+// exportTodoTxt writes one item per line following the todo.txt format:
+// completion mark and date, priority, creation date, task, then
+// projects (+tag), contexts (@tag), due date and the other keys.
+// The words of the task that would be read as such are escaped
+func (list *List) exportTodoTxt(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	for _, v := range list.Items {
+		var words []string
+		switch {
+		case v.Done:
+			words = append(words, "x")
+			if !v.CompletedAt.IsZero() {
+				words = append(words, v.CompletedAt.Format(DateFormat))
+				if !v.CreateAt.IsZero() {
+					words = append(words, v.CreateAt.Format(DateFormat))
+				}
+			}
+		default:
+			if v.Priority != "" {
+				words = append(words, "("+v.Priority+")")
+			}
+			if !v.CreateAt.IsZero() {
+				words = append(words, v.CreateAt.Format(DateFormat))
+			}
+		}
+
+		task := escapeWords(v.Task)
+		// a task starting like a completion mark or a date
+		// would be read as such
+		if first := strings.Fields(task); len(first) > 0 &&
+			(first[0] == "x" || isDate(first[0])) {
+			task = `\` + task
+		}
+		words = append(words, task)
+		words = append(words, tagWords(v.Tags)...)
+		if !v.Due.IsZero() {
+			words = append(words, "due:"+FormatDue(v.Due))
+		}
+		if v.Recur != "" {
+			words = append(words, "rec:"+v.Recur)
+		}
+		// completed tasks lose their priority in todo.txt,
+		// keep it as a key instead
+		if v.Done && v.Priority != "" {
+			words = append(words, "pri:"+v.Priority)
+		}
+		if v.Notes != "" {
+			words = append(words, "note:"+url.PathEscape(v.Notes))
+		}
+
+		fmt.Fprintln(bw, strings.Join(words, " "))
+	}
+	return bw.Flush()
+}
+
+// parseTodoTxt parses a line in the todo.txt format
+func parseTodoTxt(line string) (item, bool, error) {
+	words := strings.Fields(line)
+	if len(words) == 0 {
+		return item{}, false, nil
+	}
+
+	i := item{}
+	date := func() (time.Time, bool) {
+		if len(words) == 0 {
+			return time.Time{}, false
+		}
+		d, err := time.ParseInLocation(DateFormat, words[0], time.Local)
+		if err != nil {
+			return time.Time{}, false
+		}
+		words = words[1:]
+		return d, true
+	}
+
+	if words[0] == "x" {
+		i.Done = true
+		words = words[1:]
+		if d, ok := date(); ok {
+			i.CompletedAt = d
+			i.CreateAt, _ = date()
+		}
+	} else {
+		if priorityWord.MatchString(words[0]) {
+			i.Priority = words[0][1:2]
+			words = words[1:]
+		}
+		i.CreateAt, _ = date()
+	}
+
+	if err := parseWords(&i, words); err != nil {
+		return item{}, false, err
+	}
+	return i, true, nil
+}
+
+// exportMarkdown writes the items as a task list, with their
+// notes indented below them
+func (list *List) exportMarkdown(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	for _, v := range list.Items {
+		mark := " "
+		if v.Done {
+			mark = "x"
+		}
+		fmt.Fprintf(bw, "- [%s] %s%s\n", mark, escapeWords(v.Task), v.details())
+
+		if v.Notes == "" {
+			continue
+		}
+		for _, line := range strings.Split(v.Notes, "\n") {
+			// notes looking like an item would be read as one
+			if markdownItem.MatchString(line) || strings.HasPrefix(line, `\`) {
+				line = `\` + line
+			}
+			if line == "" {
+				fmt.Fprintln(bw)
+				continue
+			}
+			fmt.Fprintf(bw, "  %s\n", line)
+		}
+	}
+	return bw.Flush()
+}
+
+// importMarkdown reads the task list items of r, with the lines
+// indented below an item as its notes. Other lines are skipped
+func importMarkdown(r io.Reader) ([]item, error) {
+	var items []item
+	// the index of the item the notes go to, and the blank lines
+	// seen, only part of the notes when more notes follow
+	cur, blank := -1, 0
+
+	s := bufio.NewScanner(r)
+	for n := 1; s.Scan(); n++ {
+		line := s.Text()
+		i, ok, err := parseMarkdown(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+
+		switch {
+		case ok:
+			items = append(items, i)
+			cur, blank = len(items)-1, 0
+		case cur < 0:
+		case strings.TrimSpace(line) == "":
+			blank++
+		case strings.HasPrefix(line, "  ") || strings.HasPrefix(line, "\t"):
+			note := strings.TrimPrefix(line, "\t")
+			if note == line {
+				note = line[2:]
+			}
+			note = strings.TrimPrefix(note, `\`)
+			if items[cur].Notes != "" {
+				note = strings.Repeat("\n", blank+1) + note
+			}
+			items[cur].Notes += note
+			blank = 0
+		default:
+			// anything else, such as a heading, ends the notes
+			cur = -1
+		}
+	}
+	return items, s.Err()
+}
+
+// parseMarkdown parses a GitHub-flavoured Markdown task list item.
+// Other lines, such as headings, are skipped
+func parseMarkdown(line string) (item, bool, error) {
+	m := markdownItem.FindStringSubmatch(line)
+	if m == nil {
+		return item{}, false, nil
+	}
+
+	i := item{Done: m[1] != " "}
+	if err := parseWords(&i, strings.Fields(m[2])); err != nil {
+		return item{}, false, err
+	}
+	return i, true, nil
+}
+
+// parseWords sets the task of i from words, taking out the details
+// written by details and exportTodoTxt: priority, due date,
+// recurrence, notes and tags. Words escaped with a backslash are
+// part of the task
+func parseWords(i *item, words []string) error {
+	var task []string
+	for _, w := range words {
+		switch {
+		case strings.HasPrefix(w, `\`):
+			task = append(task, w[1:])
+		case priorityWord.MatchString(w):
+			i.Priority = w[1:2]
+		case strings.HasPrefix(w, "pri:") && len(w) == 5:
+			p, err := parsePriority(w[4:])
+			if err != nil {
+				return err
+			}
+			i.Priority = p
+		case strings.HasPrefix(w, "due:"):
+			d, err := ParseDue(w[4:])
+			if err != nil {
+				return fmt.Errorf("invalid due date %q: %w", w, err)
+			}
+			i.Due = d
+		case strings.HasPrefix(w, "note:"):
+			notes, err := url.PathUnescape(w[5:])
+			if err != nil {
+				return fmt.Errorf("invalid notes %q: %w", w, err)
+			}
+			i.Notes = notes
+		case strings.HasPrefix(w, "rec:"):
+			r, err := ParseRecurrence(w[4:])
+			if err != nil {
+				return err
+			}
+			i.Recur = r.String()
+		case len(w) > 1 && w[0] == '+':
+			i.Tags = append(i.Tags, w[1:])
+		case len(w) > 1 && w[0] == '@':
+			i.Tags = append(i.Tags, w)
+		default:
+			task = append(task, w)
+		}
+	}
+
+	i.Task = strings.Join(task, " ")
+	if i.Task == "" {
+		return fmt.Errorf("task cannot be blank")
+	}
+	return nil
+}
+
+// isDetail reports whether parseWords reads the word as a detail
+func isDetail(w string) bool {
+	switch {
+	case priorityWord.MatchString(w),
+		strings.HasPrefix(w, "pri:") && len(w) == 5,
+		strings.HasPrefix(w, "due:"),
+		strings.HasPrefix(w, "rec:"),
+		strings.HasPrefix(w, "note:"),
+		len(w) > 1 && (w[0] == '+' || w[0] == '@'):
+		return true
+	}
+	return false
+}
+
+// escapeWords escapes with a backslash the words of text parseWords
+// would read as details, and the ones already starting with one
+func escapeWords(text string) string {
+	words := strings.Fields(text)
+	for k, w := range words {
+		if strings.HasPrefix(w, `\`) || isDetail(w) {
+			words[k] = `\` + w
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+// isDate reports whether the word is a date in DateFormat
+func isDate(w string) bool {
+	_, err := time.Parse(DateFormat, w)
+	return err == nil
+}
+
+// tagWords writes tags as todo.txt projects, except
+// for tags already written as contexts
+func tagWords(tags []string) []string {
+	words := make([]string, 0, len(tags))
+	for _, t := range tags {
+		if !strings.HasPrefix(t, "@") {
+			t = "+" + t
+		}
+		words = append(words, t)
+	}
+	return words
+}
+
+// importLines parses each line of r with parse, which
+// reports whether the line holds an item
+func importLines(r io.Reader, parse func(string) (item, bool, error)) ([]item, error) {
+	var items []item
+	s := bufio.NewScanner(r)
+	for n := 1; s.Scan(); n++ {
+		i, ok, err := parse(s.Text())
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+		if ok {
+			items = append(items, i)
+		}
+	}
+	return items, s.Err()
+}
+
+func (list *List) exportCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+
+	formatTime := func(t time.Time) string {
+		if t.IsZero() {
+			return ""
+		}
+		return t.Format(time.RFC3339)
+	}
+	for _, v := range list.Items {
+		record := []string{
+			strconv.Itoa(v.ID),
+			v.Task,
+			strconv.FormatBool(v.Done),
+			formatTime(v.CreateAt),
+			formatTime(v.CompletedAt),
+			v.Priority,
+			formatTime(v.Due),
+			strings.Join(v.Tags, " "),
+			v.Notes,
+			v.Recur,
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// importCSV reads records with the columns written by exportCSV,
+// in any order. Only the task column is required
+func importCSV(r io.Reader) ([]item, error) {
+	cr := csv.NewReader(r)
+	header, err := cr.Read()
+	if err == io.EOF {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	col := map[string]int{}
+	for k, name := range header {
+		col[strings.ToLower(strings.TrimSpace(name))] = k
+	}
+	if _, ok := col["task"]; !ok {
+		return nil, fmt.Errorf("missing CSV column %q", "task")
+	}
+
+	var items []item
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		// records can span several lines
+		n, _ := cr.FieldPos(0)
+
+		field := func(name string) string {
+			if k, ok := col[name]; ok && k < len(record) {
+				return strings.TrimSpace(record[k])
+			}
+			return ""
+		}
+		parseTime := func(name string) (time.Time, error) {
+			v := field(name)
+			if v == "" {
+				return time.Time{}, nil
+			}
+			if t, err := time.Parse(time.RFC3339, v); err == nil {
+				return t, nil
+			}
+			return time.ParseInLocation(DateFormat, v, time.Local)
+		}
+
+		i := item{Task: field("task")}
+		if i.Task == "" {
+			return nil, fmt.Errorf("line %d: task cannot be blank", n)
+		}
+		if i.Priority, err = parsePriority(field("priority")); err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+		if v := field("done"); v != "" {
+			if i.Done, err = strconv.ParseBool(v); err != nil {
+				return nil, fmt.Errorf("line %d: %w", n, err)
+			}
+		}
+		if i.CreateAt, err = parseTime("created"); err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+		if i.CompletedAt, err = parseTime("completed"); err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+		if i.Due, err = parseTime("due"); err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+		i.Tags = strings.Fields(field("tags"))
+		i.Notes = field("notes")
+		if v := field("recur"); v != "" {
+			r, err := ParseRecurrence(v)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", n, err)
+			}
+			i.Recur = r.String()
+		}
+
+		items = append(items, i)
+	}
+	return items, nil
+}
@@ -0,0 +1,153 @@
+package todo_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+	"todo"
+)
+
+func TestList_ExportImport(t *testing.T) {
+	ls := todo.List{}
+
+	id := ls.Add("Write report, with a comma")
+	ls.SetPriority(id, "A")
+	ls.SetDue(id, time.Date(2026, time.November, 1, 0, 0, 0, 0, time.Local))
+	ls.AddTags(id, "work", "@office")
+	ls.SetRecurrence(id, "weekly:mon")
+	ls.SetNotes(id, "Numbers from Q3.\nSend to \"Ann\", Bob.")
+
+	id = ls.Add("Buy \"milk\"")
+	ls.SetPriority(id, "C")
+	ls.AddTags(id, "home")
+	ls.Complete(id)
+
+	ls.Add("Plain task")
+
+	// text looking like details or todo.txt marks stays in the task
+	ls.Add("email bob about +1 due:tomorrow (A) @home")
+	ls.Add("x marks the spot")
+	id = ls.Add("2026-10-18 meeting, pri:B rec:daily note:x \\path")
+	ls.SetNotes(id, "- [ ] not an item\n\n  indented\n\\escaped")
+
+	for _, format := range []string{todo.FormatTodoTxt, todo.FormatCSV, todo.FormatMarkdown} {
+		t.Run(format, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := ls.Export(&buf, format); err != nil {
+				t.Fatal(err)
+			}
+
+			imported := todo.List{}
+			imported.Add("Existing task")
+			n, err := imported.Import(&buf, format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if n != len(ls.Items) {
+				t.Fatalf("Expected %d items imported, got %d instead.", len(ls.Items), n)
+			}
+
+			// imported items follow the existing ones
+			for k, exp := range ls.Items {
+				got := imported.Items[k+1]
+				if got.ID != k+2 {
+					t.Errorf("Expected ID %d, got %d instead.", k+2, got.ID)
+				}
+				if got.Task != exp.Task || got.Done != exp.Done ||
+					got.Priority != exp.Priority || !got.Due.Equal(exp.Due) || got.Recur != exp.Recur ||
+					strings.Join(got.Tags, " ") != strings.Join(exp.Tags, " ") {
+					t.Errorf("Expected %+v, got %+v instead.", exp, got)
+				}
+				if got.Notes != exp.Notes {
+					t.Errorf("Expected notes %q, got %q instead.", exp.Notes, got.Notes)
+				}
+			}
+		})
+	}
+}
+
+func TestList_ImportTodoTxt(t *testing.T) {
+	data := `(A) 2026-10-01 Call Mom +Family @phone due:2026-10-20
+x 2026-10-18 2026-10-02 Pay bills pri:B
+
+x Done without dates
+`
+	ls := todo.List{}
+	if _, err := ls.Import(strings.NewReader(data), todo.FormatTodoTxt); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "  1: Call Mom (A) due:2026-10-20 @phone +Family\n" +
+		"X 2: Pay bills (B)\n" +
+		"X 3: Done without dates\n"
+	if ls.String() != expected {
+		t.Errorf("Expected %q, got %q instead.", expected, ls.String())
+	}
+
+	created := time.Date(2026, time.October, 1, 0, 0, 0, 0, time.Local)
+	if !ls.Items[0].CreateAt.Equal(created) {
+		t.Errorf("Expected creation date %s, got %s instead.", created, ls.Items[0].CreateAt)
+	}
+	completed := time.Date(2026, time.October, 18, 0, 0, 0, 0, time.Local)
+	if !ls.Items[1].CompletedAt.Equal(completed) {
+		t.Errorf("Expected completion date %s, got %s instead.", completed, ls.Items[1].CompletedAt)
+	}
+}
+
+func TestList_ImportCSVInvalid(t *testing.T) {
+	testCases := []struct {
+		name string
+		data string
+		exp  string
+	}{
+		{"Priority", "task,priority\nCall Mom,a\nPay bills,high\n",
+			`line 3: invalid priority "HIGH"`},
+		{"MultilineNotes", "task,notes,done\nCall Mom,\"first\nsecond\",maybe\n",
+			`line 2: strconv.ParseBool`},
+		{"BlankTask", "task\nCall Mom\n \n", "line 3: task cannot be blank"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ls := todo.List{}
+			_, err := ls.Import(strings.NewReader(tc.data), todo.FormatCSV)
+			if err == nil || !strings.Contains(err.Error(), tc.exp) {
+				t.Fatalf("Expected error %q, got %v instead.", tc.exp, err)
+			}
+			if len(ls.Items) != 0 {
+				t.Errorf("Expected no items imported, got %d.", len(ls.Items))
+			}
+		})
+	}
+}
+
+func TestList_ExportMarkdown(t *testing.T) {
+	ls := todo.List{}
+	ls.Add("Task 1")
+	ls.Add("Task 2")
+	ls.Complete(2)
+
+	var buf bytes.Buffer
+	if err := ls.Export(&buf, todo.FormatMarkdown); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "- [ ] Task 1\n- [x] Task 2\n"
+	if buf.String() != expected {
+		t.Errorf("Expected %q, got %q instead.", expected, buf.String())
+	}
+}
+
+func TestFormatFromFilename(t *testing.T) {
+	testCases := map[string]string{
+		"todo.txt":     todo.FormatTodoTxt,
+		"tasks.CSV":    todo.FormatCSV,
+		"README.md":    todo.FormatMarkdown,
+		"no-extension": todo.FormatTodoTxt,
+	}
+	for name, exp := range testCases {
+		if got := todo.FormatFromFilename(name); got != exp {
+			t.Errorf("%s: expected %q, got %q instead.", name, exp, got)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package todo
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// Lock acquires an exclusive advisory lock on the given todo file,
+// waiting until other processes release it. Hold the lock across
+// Get, the changes and Save so concurrent updates aren't lost.
+// The lock lives in a separate ".lock" file because saving
+// replaces the todo file itself
+func Lock(filename string) (unlock func() error, err error) {
+	f, err := os.OpenFile(filename+".lock", os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := lockFile(f); err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	// closing the file releases the lock
+	return f.Close, nil
+}
+
+// writeFileAtomic writes data to a temporary file in the same directory
+// and renames it over filename once it's safely on disk, so readers
+// and crashes never see a partially written file
+func writeFileAtomic(filename string, data []byte, perm os.FileMode) error {
+	// keep the permissions of the file being replaced
+	if fi, err := os.Stat(filename); err == nil {
+		perm = fi.Mode().Perm()
+	}
+
+	dir := filepath.Dir(filename)
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(filename)+".tmp*")
+	if err != nil {
+		return err
+	}
+	// no-op once the temporary file has been renamed
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), perm); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), filename); err != nil {
+		return err
+	}
+
+	syncDir(dir)
+	return nil
+}
+
+// syncDir flushes the directory entry of a renamed file to disk.
+// It's best effort as some platforms can't sync directories
+func syncDir(dir string) {
+	d, err := os.Open(dir)
+	if err != nil {
+		return
+	}
+	d.Sync()
+	d.Close()
+}
@@ -0,0 +1,96 @@
+package todo_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"todo"
+)
+
+func TestUpdate_Concurrent(t *testing.T) {
+	dir, err := ioutil.TempDir("", "todo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	filename := filepath.Join(dir, ".todo.json")
+	store, err := todo.NewStorage(todo.StorageJSON, filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const workers = 20
+	var wg sync.WaitGroup
+	errs := make(chan error, workers)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs <- todo.Update(store, func(list *todo.List) error {
+				list.Add(fmt.Sprintf("Task %d", i))
+				return nil
+			})
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ls := todo.List{}
+	if err := ls.Get(filename); err != nil {
+		t.Fatal(err)
+	}
+	if len(ls.Items) != workers {
+		t.Errorf("Expected %d items, got %d instead.", workers, len(ls.Items))
+	}
+
+	// only the todo file and its lock should be left behind
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 2 {
+		t.Errorf("Expected 2 files in %s, got %d instead.", dir, len(files))
+	}
+}
+
+func TestUpdate_Error(t *testing.T) {
+	tf, err := ioutil.TempFile("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tf.Close()
+	defer os.Remove(tf.Name())
+	defer os.Remove(tf.Name() + ".lock")
+
+	store, err := todo.NewStorage(todo.StorageJSON, tf.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	errExp := fmt.Errorf("failed")
+	err = todo.Update(store, func(list *todo.List) error {
+		list.Add("New Task")
+		return errExp
+	})
+	if err != errExp {
+		t.Fatalf("Expected error %q, got %v instead.", errExp, err)
+	}
+
+	ls := todo.List{}
+	if err := ls.Get(tf.Name()); err != nil {
+		t.Fatal(err)
+	}
+	if len(ls.Items) != 0 {
+		t.Errorf("Expected list not to be saved, got %d items.", len(ls.Items))
+	}
+}
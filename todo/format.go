@@ -0,0 +1,125 @@
+package todo
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+	"time"
+)
+
+// Output formats accepted by NewFormatter. Templates are
+// given as OutputTemplate followed by the template text
+const (
+	OutputPlain    = "plain"
+	OutputTable    = "table"
+	OutputJSON     = "json"
+	OutputTemplate = "template:"
+)
+
+// Formatter renders a list for display or for other programs
+type Formatter interface {
+	Format(w io.Writer, list List) error
+}
+
+// FormatterFunc adapts a function to the Formatter interface
+type FormatterFunc func(w io.Writer, list List) error
+
+// Format calls f(w, list)
+func (f FormatterFunc) Format(w io.Writer, list List) error {
+	return f(w, list)
+}
+
+// NewFormatter returns the formatter for the given output format:
+//
+//	plain             one item per line, as List.String
+//	table             aligned columns with the item details and dates
+//	json              a JSON array of items
+//	template:TEXT     the Go template TEXT executed for each item
+//
+// An empty format means plain
+func NewFormatter(format string) (Formatter, error) {
+	switch {
+	case format == "" || format == OutputPlain:
+		return FormatterFunc(formatPlain), nil
+	case format == OutputTable:
+		return FormatterFunc(formatTable), nil
+	case format == OutputJSON:
+		return FormatterFunc(formatJSON), nil
+	case strings.HasPrefix(format, OutputTemplate):
+		return newTemplateFormatter(strings.TrimPrefix(format, OutputTemplate))
+	}
+	return nil, fmt.Errorf("unknown output format %q: must be %s, %s, %s or %sTEXT",
+		format, OutputPlain, OutputTable, OutputJSON, OutputTemplate)
+}
+
+func formatPlain(w io.Writer, list List) error {
+	_, err := io.WriteString(w, list.String())
+	return err
+}
+
+func formatTable(w io.Writer, list List) error {
+	tw := tabwriter.NewWriter(w, 0, 2, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tDONE\tTASK\tPRI\tDUE\tCREATED\tCOMPLETED\tTAGS")
+
+	items, depths := list.tree()
+	for k, v := range items {
+		done := ""
+		if v.Done {
+			done = "X"
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s%s\t%s\t%s\t%s\t%s\t%s\n", v.ID, done,
+			strings.Repeat("  ", depths[k]), v.Task, v.Priority, formatDue(v.Due),
+			formatDate(v.CreateAt), formatDate(v.CompletedAt),
+			strings.Join(tagWords(v.Tags), " "))
+	}
+	return tw.Flush()
+}
+
+func formatJSON(w io.Writer, list List) error {
+	return json.NewEncoder(w).Encode(list)
+}
+
+// newTemplateFormatter parses text as a template executed for
+// each item, adding a new line after each one. Besides the
+// item fields, templates can use the functions:
+//
+//	date    format a time as YYYY-MM-DD, empty for zero times
+//	join    join strings with a separator
+func newTemplateFormatter(text string) (Formatter, error) {
+	tmpl, err := template.New("item").Funcs(template.FuncMap{
+		"date": formatDate,
+		"join": func(sep string, s []string) string { return strings.Join(s, sep) },
+	}).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid template: %w", err)
+	}
+
+	return FormatterFunc(func(w io.Writer, list List) error {
+		for _, v := range list.Items {
+			if err := tmpl.Execute(w, v); err != nil {
+				return err
+			}
+			if _, err := io.WriteString(w, "\n"); err != nil {
+				return err
+			}
+		}
+		return nil
+	}), nil
+}
+
+func formatDue(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return FormatDue(t)
+}
+
+func formatDate(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(DateFormat)
+}
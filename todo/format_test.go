@@ -0,0 +1,98 @@
+package todo_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+	"todo"
+)
+
+func TestFormatter(t *testing.T) {
+	ls := todo.List{}
+
+	created := time.Date(2026, time.October, 1, 9, 0, 0, 0, time.Local)
+	id := ls.Add("Write report")
+	ls.SetPriority(id, "A")
+	ls.SetDue(id, time.Date(2026, time.October, 20, 0, 0, 0, 0, time.Local))
+	ls.AddTags(id, "work")
+	id = ls.Add("Buy milk")
+	ls.Complete(id)
+	for i := range ls.Items {
+		ls.Items[i].CreateAt = created
+	}
+	ls.Items[1].CompletedAt = created.AddDate(0, 0, 1)
+
+	testCases := []struct {
+		name   string
+		format string
+		exp    string
+	}{
+		{name: "Default", format: "", exp: ls.String()},
+		{name: "Plain", format: todo.OutputPlain, exp: ls.String()},
+		{
+			name:   "Table",
+			format: todo.OutputTable,
+			exp: "ID  DONE  TASK          PRI  DUE         CREATED     COMPLETED   TAGS\n" +
+				"1         Write report  A    2026-10-20  2026-10-01              +work\n" +
+				"2   X     Buy milk                       2026-10-01  2026-10-02  \n",
+		},
+		{
+			name:   "Template",
+			format: `template:{{.ID}},{{.Done}},{{.Task}},{{date .Due}},{{join ";" .Tags}}`,
+			exp:    "1,false,Write report,2026-10-20,work\n2,true,Buy milk,,\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			f, err := todo.NewFormatter(tc.format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var b bytes.Buffer
+			if err := f.Format(&b, ls); err != nil {
+				t.Fatal(err)
+			}
+			if b.String() != tc.exp {
+				t.Errorf("Expected:\n%q\ngot:\n%q", tc.exp, b.String())
+			}
+		})
+	}
+
+	t.Run("JSON", func(t *testing.T) {
+		f, err := todo.NewFormatter(todo.OutputJSON)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var b bytes.Buffer
+		if err := f.Format(&b, ls); err != nil {
+			t.Fatal(err)
+		}
+		got := todo.List{}
+		if err := json.Unmarshal(b.Bytes(), &got); err != nil {
+			t.Fatal(err)
+		}
+		if len(got.Items) != 2 || got.Items[0].Task != "Write report" || !got.Items[1].Done {
+			t.Errorf("Unexpected items:\n%s", got.String())
+		}
+
+		b.Reset()
+		if err := f.Format(&b, todo.List{}); err != nil {
+			t.Fatal(err)
+		}
+		if strings.TrimSpace(b.String()) != "[]" {
+			t.Errorf("Expected an empty array, got %q", b.String())
+		}
+	})
+}
+
+func TestNewFormatter_Errors(t *testing.T) {
+	for _, format := range []string{"yaml", "template:{{.ID"} {
+		if _, err := todo.NewFormatter(format); err == nil {
+			t.Errorf("Expected error for format %q, got nil", format)
+		}
+	}
+}
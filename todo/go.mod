@@ -1,3 +1,18 @@
 module todo
 
 go 1.17
+
+require github.com/mattn/go-sqlite3 v1.14.16
+
+require (
+	distributing/notify v0.0.0
+	github.com/spf13/cobra v1.6.1
+	golang.org/x/crypto v0.5.0
+)
+
+require (
+	github.com/inconshreveable/mousetrap v1.0.1 // indirect
+	github.com/spf13/pflag v1.0.5 // indirect
+)
+
+replace distributing/notify => ../distributing/notify
@@ -0,0 +1,527 @@
+package todo
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Journal operations recorded by the journal itself
+const (
+	OpInit = "init"
+	OpUndo = "undo"
+	OpRedo = "redo"
+)
+
+// OpArchive is the operation moving items to the archive. Neither
+// it nor the operations before it can be undone, since the archived
+// items would be both in the list and in the archive
+const OpArchive = "archive"
+
+// OpExternal records the changes made to the list without the
+// journal, by the server for instance. The operations before it
+// can't be undone, since that would overwrite those changes
+const OpExternal = "external"
+
+var (
+	ErrNothingToUndo = errors.New("nothing to undo")
+	ErrNothingToRedo = errors.New("nothing to redo")
+	ErrListChanged   = errors.New("the list changed since the last recorded change")
+)
+
+// Change records the state of one item before and after an operation.
+// Before is nil for added items and After is nil for deleted ones.
+// The positions are the item indexes in the list, or -1 when absent
+type Change struct {
+	ID     int
+	OldPos int
+	NewPos int
+	Before *item `json:",omitempty"`
+	After  *item `json:",omitempty"`
+}
+
+// Entry is an operation recorded in the journal.
+// Undo and redo entries refer to the entry they undo or redo
+type Entry struct {
+	Seq     int
+	Time    time.Time
+	Op      string
+	Ref     int `json:",omitempty"`
+	Changes []Change
+	// Hash identifies the list right after the entry,
+	// empty in entries recorded before it was kept
+	Hash string `json:",omitempty"`
+}
+
+// Journal is an append-only log of the operations on a list,
+// kept as one JSON entry per line, encrypted when it has a key.
+// Applying the changes of every entry in order
+// rebuilds the list from scratch
+type Journal struct {
+	filename string
+	key      *Key
+}
+
+// NewJournal creates a journal kept in the given file,
+// encrypted with the key set by WithKey
+func NewJournal(filename string, opts ...Option) *Journal {
+	return &Journal{filename: filename, key: newOptions(opts).key}
+}
+
+// Entries returns all the journal entries in order
+func (j *Journal) Entries() ([]Entry, error) {
+	data, err := os.ReadFile(j.filename)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	// an incomplete last line is left by a write interrupted by a crash
+	data = data[:bytes.LastIndexByte(data, '\n')+1]
+
+	var entries []Entry
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		if len(line) == 0 {
+			continue
+		}
+		e, err := j.decodeEntry(line)
+		if err != nil {
+			return nil, fmt.Errorf("journal entry %d: %w", len(entries)+1, err)
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+// tail returns the last entry, with a zero sequence number for an
+// empty journal, and the size of the journal without an incomplete
+// last line. Only the end of the file is read, so recording an
+// operation doesn't parse the whole journal
+func (j *Journal) tail() (last Entry, size int64, err error) {
+	f, err := os.Open(j.filename)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return last, 0, nil
+		}
+		return last, 0, err
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return last, 0, err
+	}
+
+	// read blocks backwards until the last whole line is read
+	var buf []byte
+	size = -1
+	for start := fi.Size(); start > 0; {
+		n := int64(4096)
+		if n > start {
+			n = start
+		}
+		start -= n
+		block := make([]byte, n)
+		if _, err := f.ReadAt(block, start); err != nil {
+			return last, 0, err
+		}
+		buf = append(block, buf...)
+
+		if size < 0 {
+			// skip what follows the last newline, left by
+			// a write interrupted by a crash
+			k := bytes.LastIndexByte(buf, '\n')
+			if k < 0 {
+				continue
+			}
+			size = start + int64(k) + 1
+			buf = buf[:k+1]
+		}
+
+		line := bytes.TrimRight(buf, "\n")
+		i := bytes.LastIndexByte(line, '\n')
+		if i < 0 && start > 0 {
+			continue
+		}
+		if line = line[i+1:]; len(line) == 0 {
+			return last, size, nil
+		}
+		if last, err = j.decodeEntry(line); err != nil {
+			return last, size, fmt.Errorf("last journal entry: %w", err)
+		}
+		return last, size, nil
+	}
+	if size < 0 {
+		size = 0
+	}
+	return last, size, nil
+}
+
+// Record appends an operation that turned before into after.
+// The first entry of a journal for a list that isn't empty is an
+// init entry with its items, so the list can be fully rebuilt.
+// Changes made to before since the last entry without the journal
+// are recorded first as an external entry
+func (j *Journal) Record(op string, before, after List) error {
+	last, _, err := j.tail()
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case last.Seq == 0 && len(before.Items) > 0:
+		if err := j.append(Entry{Op: OpInit, Changes: Diff(List{}, before), Hash: before.hash()}); err != nil {
+			return err
+		}
+	case last.Hash != "" && last.Hash != before.hash():
+		prev, err := j.ListAt(last.Seq)
+		if err != nil {
+			return err
+		}
+		if err := j.append(Entry{Op: OpExternal, Changes: Diff(prev, before), Hash: before.hash()}); err != nil {
+			return err
+		}
+	}
+
+	changes := Diff(before, after)
+	if len(changes) == 0 {
+		return nil
+	}
+	return j.append(Entry{Op: op, Changes: changes, Hash: after.hash()})
+}
+
+// Revision is an undo or redo applied to a list,
+// to record with Commit once the list is saved
+type Revision struct {
+	// Target is the entry undone or redone
+	Target Entry
+	entry  Entry
+}
+
+// Undo reverts the last operation not undone yet on list, which
+// must be the list as of the last entry, or ErrListChanged is returned.
+// Save the list, then commit the revision to keep the change
+func (j *Journal) Undo(list *List) (Revision, error) {
+	return j.revisit(list, OpUndo)
+}
+
+// Redo applies again the last undone operation on list, which
+// must be the list as of the last entry, or ErrListChanged is returned.
+// Save the list, then commit the revision to keep the change
+func (j *Journal) Redo(list *List) (Revision, error) {
+	return j.revisit(list, OpRedo)
+}
+
+// Commit records in the journal a revision returned by Undo or Redo
+func (j *Journal) Commit(r Revision) error {
+	return j.append(r.entry)
+}
+
+func (j *Journal) revisit(list *List, op string) (Revision, error) {
+	entries, err := j.Entries()
+	if err != nil {
+		return Revision{}, err
+	}
+	if n := len(entries); n > 0 && entries[n-1].Hash != "" && entries[n-1].Hash != list.hash() {
+		return Revision{}, ErrListChanged
+	}
+
+	undo, redo := stacks(entries)
+	stack, errEmpty := undo, ErrNothingToUndo
+	if op == OpRedo {
+		stack, errEmpty = redo, ErrNothingToRedo
+	}
+	if len(stack) == 0 {
+		return Revision{}, errEmpty
+	}
+
+	target := entries[stack[len(stack)-1]-1]
+	changes := target.Changes
+	if op == OpUndo {
+		changes = invert(changes)
+	}
+	list.apply(changes)
+
+	return Revision{
+		Target: target,
+		entry:  Entry{Op: op, Ref: target.Seq, Changes: changes, Hash: list.hash()},
+	}, nil
+}
+
+// ListAt rebuilds the list as it was right after the entry with
+// the given sequence number
+func (j *Journal) ListAt(seq int) (List, error) {
+	entries, err := j.Entries()
+	if err != nil {
+		return List{}, err
+	}
+	if seq < 1 || seq > len(entries) {
+		return List{}, fmt.Errorf("journal entry %d does not exist", seq)
+	}
+
+	list := List{}
+	for _, e := range entries[:seq] {
+		list.apply(e.Changes)
+	}
+	return list, nil
+}
+
+// Rewrite writes again all the entries of the journal, encrypting
+// them when it has a key, or decrypting them otherwise
+func (j *Journal) Rewrite() error {
+	entries, err := j.Entries()
+	if err != nil || len(entries) == 0 {
+		return err
+	}
+
+	var b bytes.Buffer
+	for _, e := range entries {
+		line, err := j.encodeEntry(e)
+		if err != nil {
+			return err
+		}
+		b.Write(line)
+	}
+	return writeFileAtomic(j.filename, b.Bytes(), 0644)
+}
+
+// append writes the entry at the end of the journal, numbering it
+// after the last entry and dropping an incomplete last line
+func (j *Journal) append(e Entry) error {
+	last, size, err := j.tail()
+	if err != nil {
+		return err
+	}
+	e.Seq = last.Seq + 1
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	line, err := j.encodeEntry(e)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(j.filename, os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if err := f.Truncate(size); err != nil {
+		f.Close()
+		return err
+	}
+	if _, err := f.WriteAt(line, size); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// encodeEntry returns the journal line for the entry, which
+// is encrypted and kept in base64 when the journal has a key
+func (j *Journal) encodeEntry(e Entry) ([]byte, error) {
+	js, err := json.Marshal(e)
+	if err != nil {
+		return nil, err
+	}
+	if j.key != nil {
+		enc, err := j.key.encrypt(js)
+		if err != nil {
+			return nil, err
+		}
+		js = []byte(base64.StdEncoding.EncodeToString(enc))
+	}
+	return append(js, '\n'), nil
+}
+
+// decodeEntry decodes a journal line, decrypting it when
+// it's an encrypted entry kept in base64
+func (j *Journal) decodeEntry(line []byte) (Entry, error) {
+	var e Entry
+	if line[0] != '{' {
+		enc, err := base64.StdEncoding.DecodeString(string(line))
+		if err != nil {
+			return e, fmt.Errorf("invalid entry: %w", err)
+		}
+		if line, err = j.key.decrypt(enc); err != nil {
+			return e, err
+		}
+	}
+	if err := json.Unmarshal(line, &e); err != nil {
+		return e, fmt.Errorf("invalid entry: %w", err)
+	}
+	return e, nil
+}
+
+// stacks replays the journal and returns the sequence numbers of
+// the entries that can be undone and redone, the last ones on top
+func stacks(entries []Entry) (undo, redo []int) {
+	for _, e := range entries {
+		switch e.Op {
+		case OpInit:
+		case OpArchive, OpExternal:
+			undo, redo = nil, nil
+		case OpUndo:
+			undo = undo[:len(undo)-1]
+			redo = append(redo, e.Ref)
+		case OpRedo:
+			redo = redo[:len(redo)-1]
+			undo = append(undo, e.Ref)
+		default:
+			undo = append(undo, e.Seq)
+			redo = nil
+		}
+	}
+	return undo, redo
+}
+
+// hash identifies the content of the list,
+// to tell whether it changed without the journal
+func (l List) hash() string {
+	js, err := json.Marshal(l.Items)
+	if err != nil {
+		return ""
+	}
+	return fmt.Sprintf("%x", sha256.Sum256(js))
+}
+
+// String describes the entry in one line
+func (e Entry) String() string {
+	desc := make([]string, 0, len(e.Changes))
+	for _, c := range e.Changes {
+		switch {
+		case c.Before == nil:
+			desc = append(desc, fmt.Sprintf("+%d %q", c.ID, c.After.Task))
+		case c.After == nil:
+			desc = append(desc, fmt.Sprintf("-%d %q", c.ID, c.Before.Task))
+		default:
+			desc = append(desc, fmt.Sprintf("~%d %q", c.ID, c.After.Task))
+		}
+	}
+
+	op := e.Op
+	if e.Ref > 0 {
+		op = fmt.Sprintf("%s %d", e.Op, e.Ref)
+	}
+	return fmt.Sprintf("%d %s %s: %s", e.Seq, e.Time.Format("2006-01-02 15:04:05"),
+		op, strings.Join(desc, ", "))
+}
+
+// Diff returns the changes that turn before into after. Items keeping
+// their content and their order relative to the other kept items
+// aren't part of the changes
+func Diff(before, after List) []Change {
+	oldPos := map[int]int{}
+	for i, v := range before.Items {
+		oldPos[v.ID] = i
+	}
+	newPos := map[int]int{}
+	for i, v := range after.Items {
+		newPos[v.ID] = i
+	}
+
+	// order of the items in both lists, to find moved items
+	var oldOrder, newOrder []int
+	for _, v := range before.Items {
+		if _, ok := newPos[v.ID]; ok {
+			oldOrder = append(oldOrder, v.ID)
+		}
+	}
+	rank := map[int]int{}
+	for _, v := range after.Items {
+		if _, ok := oldPos[v.ID]; ok {
+			rank[v.ID] = len(newOrder)
+			newOrder = append(newOrder, v.ID)
+		}
+	}
+	moved := map[int]bool{}
+	for i, id := range oldOrder {
+		if rank[id] != i {
+			moved[id] = true
+		}
+	}
+
+	var changes []Change
+	for i, v := range before.Items {
+		if _, ok := newPos[v.ID]; !ok {
+			b := v
+			changes = append(changes, Change{ID: v.ID, OldPos: i, NewPos: -1, Before: &b})
+		}
+	}
+	for i, v := range after.Items {
+		a := v
+		j, ok := oldPos[v.ID]
+		switch {
+		case !ok:
+			changes = append(changes, Change{ID: v.ID, OldPos: -1, NewPos: i, After: &a})
+		case moved[v.ID] || !sameItem(before.Items[j], v):
+			b := before.Items[j]
+			changes = append(changes, Change{ID: v.ID, OldPos: j, NewPos: i, Before: &b, After: &a})
+		}
+	}
+	return changes
+}
+
+func sameItem(a, b item) bool {
+	ja, errA := json.Marshal(a)
+	jb, errB := json.Marshal(b)
+	return errA == nil && errB == nil && string(ja) == string(jb)
+}
+
+// invert returns the changes reverting the given changes
+func invert(changes []Change) []Change {
+	inv := make([]Change, len(changes))
+	for i, c := range changes {
+		inv[i] = Change{ID: c.ID, OldPos: c.NewPos, NewPos: c.OldPos,
+			Before: c.After, After: c.Before}
+	}
+	return inv
+}
+
+// apply changes the list as described by changes: the changed items are
+// removed and their new state is inserted back at its new position
+func (list *List) apply(changes []Change) {
+	changed := map[int]bool{}
+	for _, c := range changes {
+		changed[c.ID] = true
+	}
+
+	ls := []item{}
+	for _, v := range list.Items {
+		if !changed[v.ID] {
+			ls = append(ls, v)
+		}
+	}
+
+	inserts := []Change{}
+	for _, c := range changes {
+		if c.After != nil {
+			inserts = append(inserts, c)
+		}
+	}
+	sort.Slice(inserts, func(i, j int) bool {
+		return inserts[i].NewPos < inserts[j].NewPos
+	})
+	for _, c := range inserts {
+		pos := c.NewPos
+		if pos > len(ls) {
+			pos = len(ls)
+		}
+		ls = append(ls, item{})
+		copy(ls[pos+1:], ls[pos:])
+		ls[pos] = *c.After
+	}
+
+	list.Items = ls
+}
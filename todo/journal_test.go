@@ -0,0 +1,326 @@
+package todo_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+	"todo"
+)
+
+func TestJournal(t *testing.T) {
+	dir, err := ioutil.TempDir("", "todo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	j := todo.NewJournal(filepath.Join(dir, ".todo.json.journal"))
+	ls := todo.List{}
+
+	// the list already has an item when the journal starts
+	ls.Add("Task 1")
+
+	change := func(op string, fn func()) {
+		t.Helper()
+		before := ls.Clone()
+		fn()
+		if err := j.Record(op, before, ls); err != nil {
+			t.Fatal(err)
+		}
+	}
+	change("add", func() { ls.Add("Task 2") })
+	change("add", func() { ls.Add("Task 3") })
+	change("complete", func() { ls.Complete(3) })
+	change("delete", func() { ls.Delete(1) })
+
+	expected := "  2: Task 2\nX 3: Task 3\n"
+	if ls.String() != expected {
+		t.Fatalf("Expected %q, got %q instead.", expected, ls.String())
+	}
+
+	t.Run("Undo", func(t *testing.T) {
+		for _, seq := range []int{5, 4} {
+			rev, err := j.Undo(&ls)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := j.Commit(rev); err != nil {
+				t.Fatal(err)
+			}
+			if rev.Target.Seq != seq {
+				t.Errorf("Expected to undo %d, got %d instead.", seq, rev.Target.Seq)
+			}
+		}
+
+		expected := "  1: Task 1\n  2: Task 2\n  3: Task 3\n"
+		if ls.String() != expected {
+			t.Errorf("Expected %q, got %q instead.", expected, ls.String())
+		}
+	})
+
+	t.Run("Redo", func(t *testing.T) {
+		rev, err := j.Redo(&ls)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := j.Commit(rev); err != nil {
+			t.Fatal(err)
+		}
+		if rev.Target.Seq != 4 {
+			t.Errorf("Expected to redo 4, got %d instead.", rev.Target.Seq)
+		}
+
+		expected := "  1: Task 1\n  2: Task 2\nX 3: Task 3\n"
+		if ls.String() != expected {
+			t.Errorf("Expected %q, got %q instead.", expected, ls.String())
+		}
+	})
+
+	t.Run("NewChangeClearsRedo", func(t *testing.T) {
+		change("add", func() { ls.Add("Task 4") })
+		if _, err := j.Redo(&ls); !errors.Is(err, todo.ErrNothingToRedo) {
+			t.Errorf("Expected %q, got %v instead.", todo.ErrNothingToRedo, err)
+		}
+	})
+
+	t.Run("ListAt", func(t *testing.T) {
+		entries, err := j.Entries()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(entries) != 9 {
+			t.Fatalf("Expected 9 entries, got %d instead.", len(entries))
+		}
+
+		testCases := []struct {
+			seq      int
+			expected string
+		}{
+			{1, "  1: Task 1\n"},
+			{5, "  2: Task 2\nX 3: Task 3\n"},
+			{7, "  1: Task 1\n  2: Task 2\n  3: Task 3\n"},
+			{9, ls.String()},
+		}
+		for _, tc := range testCases {
+			past, err := j.ListAt(tc.seq)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if past.String() != tc.expected {
+				t.Errorf("At %d: expected %q, got %q instead.",
+					tc.seq, tc.expected, past.String())
+			}
+		}
+	})
+}
+
+func TestJournal_Move(t *testing.T) {
+	dir, err := ioutil.TempDir("", "todo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	before := todo.List{}
+	for _, task := range []string{"Task 1", "Task 2", "Task 3", "Task 4"} {
+		before.Add(task)
+	}
+	after := before.Clone()
+	after.Items[0], after.Items[1], after.Items[2] = before.Items[2], before.Items[0], before.Items[1]
+
+	j := todo.NewJournal(filepath.Join(dir, ".todo.json.journal"))
+	if err := j.Record("move", before, after); err != nil {
+		t.Fatal(err)
+	}
+
+	past, err := j.ListAt(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if past.String() != after.String() {
+		t.Errorf("Expected %q, got %q instead.", after.String(), past.String())
+	}
+
+	ls := after.Clone()
+	if _, err := j.Undo(&ls); err != nil {
+		t.Fatal(err)
+	}
+	if ls.String() != before.String() {
+		t.Errorf("Expected %q, got %q instead.", before.String(), ls.String())
+	}
+}
+
+func TestJournal_Archive(t *testing.T) {
+	dir, err := ioutil.TempDir("", "todo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	j := todo.NewJournal(filepath.Join(dir, ".todo.json.journal"))
+	ls := todo.List{}
+	change := func(op string, fn func()) {
+		t.Helper()
+		before := ls.Clone()
+		fn()
+		if err := j.Record(op, before, ls); err != nil {
+			t.Fatal(err)
+		}
+	}
+	change("add", func() { ls.Add("Task 1") })
+	change("complete", func() { ls.Complete(1) })
+	change(todo.OpArchive, func() { ls.Archive(time.Now().Add(time.Hour)) })
+
+	if _, err := j.Undo(&ls); !errors.Is(err, todo.ErrNothingToUndo) {
+		t.Errorf("Expected %q, got %v instead.", todo.ErrNothingToUndo, err)
+	}
+
+	// the changes after archiving can be undone
+	change("add", func() { ls.Add("Task 2") })
+	rev, err := j.Undo(&ls)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rev.Target.Seq != 4 {
+		t.Errorf("Expected to undo 4, got %d instead.", rev.Target.Seq)
+	}
+}
+
+func TestJournal_LongEntries(t *testing.T) {
+	dir, err := ioutil.TempDir("", "todo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// entries longer than the blocks read to find the last one
+	j := todo.NewJournal(filepath.Join(dir, ".todo.json.journal"))
+	ls := todo.List{}
+	for i := 0; i < 3; i++ {
+		before := ls.Clone()
+		ls.Add(strings.Repeat("x", 5000))
+		if err := j.Record("add", before, ls); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	entries, err := j.Entries()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, e := range entries {
+		if e.Seq != i+1 {
+			t.Errorf("Expected entry %d, got %d instead.", i+1, e.Seq)
+		}
+	}
+}
+
+func TestJournal_External(t *testing.T) {
+	dir, err := ioutil.TempDir("", "todo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	j := todo.NewJournal(filepath.Join(dir, ".todo.json.journal"))
+	ls := todo.List{}
+	change := func(op string, fn func()) {
+		t.Helper()
+		before := ls.Clone()
+		fn()
+		if err := j.Record(op, before, ls); err != nil {
+			t.Fatal(err)
+		}
+	}
+	change("add", func() { ls.Add("Task 1") })
+	change("add", func() { ls.Add("Task 2") })
+
+	// changed without the journal, by the server for instance
+	if err := ls.Edit(1, "Task 1 edited"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := j.Undo(&ls); !errors.Is(err, todo.ErrListChanged) {
+		t.Errorf("Expected %q, got %v instead.", todo.ErrListChanged, err)
+	}
+	if exp := "  1: Task 1 edited\n  2: Task 2\n"; ls.String() != exp {
+		t.Errorf("Expected %q, got %q instead.", exp, ls.String())
+	}
+
+	// the next change records the external one first
+	change("add", func() { ls.Add("Task 3") })
+	entries, err := j.Entries()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 4 || entries[2].Op != todo.OpExternal {
+		t.Fatalf("Expected an external entry before the last one, got %v instead.", entries)
+	}
+	for _, seq := range []int{3, 4} {
+		at, err := j.ListAt(seq)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasPrefix(at.String(), "  1: Task 1 edited\n") {
+			t.Errorf("Expected the external change at %d, got %q instead.", seq, at.String())
+		}
+	}
+
+	// only the change after it can be undone
+	rev, err := j.Undo(&ls)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := j.Commit(rev); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := j.Undo(&ls); !errors.Is(err, todo.ErrNothingToUndo) {
+		t.Errorf("Expected %q, got %v instead.", todo.ErrNothingToUndo, err)
+	}
+}
+
+func TestJournal_IncompleteLine(t *testing.T) {
+	dir, err := ioutil.TempDir("", "todo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	filename := filepath.Join(dir, ".todo.json.journal")
+	j := todo.NewJournal(filename)
+	ls := todo.List{}
+	ls.Add("Task 1")
+	if err := j.Record("add", todo.List{}, ls); err != nil {
+		t.Fatal(err)
+	}
+
+	// a write interrupted by a crash
+	f, err := os.OpenFile(filename, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.WriteString(`{"Seq":2,"Op":"ad`); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	if entries, err := j.Entries(); err != nil || len(entries) != 1 {
+		t.Fatalf("Expected 1 entry, got %v, %v instead.", entries, err)
+	}
+
+	before := ls.Clone()
+	ls.Add("Task 2")
+	if err := j.Record("add", before, ls); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := j.Entries()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || entries[1].Seq != 2 || entries[1].Op != "add" {
+		t.Errorf("Expected the incomplete line replaced, got %v instead.", entries)
+	}
+}
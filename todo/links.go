@@ -0,0 +1,278 @@
+package todo
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ErrBlocked is returned when completing an item with open blockers
+var ErrBlocked = errors.New("item is blocked")
+
+// SetParent makes the to-do item with the given ID a subtask of
+// the item with the parent ID, which must be in the same named list.
+// A parent ID of 0 makes it a top-level item
+func (list *List) SetParent(id, parent int) error {
+	i, err := list.IndexOf(id)
+	if err != nil {
+		return err
+	}
+
+	if parent != 0 {
+		p, err := list.IndexOf(parent)
+		if err != nil {
+			return err
+		}
+		if name := list.Items[p].listName(); name != list.Items[i].listName() {
+			return fmt.Errorf("item %d cannot be a subtask of %d: %d is in list %q", id, parent, parent, name)
+		}
+		// the new parent can't be the item itself or one of its subtasks
+		for p, n := parent, 0; p != 0 && n <= len(list.Items); p, n = list.parentOf(p), n+1 {
+			if p == id {
+				return fmt.Errorf("item %d cannot be a subtask of itself", id)
+			}
+		}
+	}
+
+	list.Items[i].Parent = parent
+
+	return nil
+}
+
+// Block marks the to-do item with the given ID as blocked by the items
+// with the blockers IDs, in the same named list, so it can't be
+// completed until they are
+func (list *List) Block(id int, blockers ...int) error {
+	i, err := list.IndexOf(id)
+	if err != nil {
+		return err
+	}
+
+	ls := list.Items
+	for _, b := range blockers {
+		j, err := list.IndexOf(b)
+		if err != nil {
+			return err
+		}
+		if b == id {
+			return fmt.Errorf("item %d cannot block itself", id)
+		}
+		if name := ls[j].listName(); name != ls[i].listName() {
+			return fmt.Errorf("item %d cannot be blocked by %d: %d is in list %q", id, b, b, name)
+		}
+		if list.blocks(id, b) {
+			return fmt.Errorf("item %d cannot be blocked by %d: %d already depends on %d", id, b, b, id)
+		}
+		if !ls[i].isBlockedBy(b) {
+			ls[i].BlockedBy = append(ls[i].BlockedBy, b)
+		}
+	}
+	sort.Ints(ls[i].BlockedBy)
+
+	return nil
+}
+
+// Unblock removes the items with the blockers IDs from the
+// blockers of the to-do item with the given ID
+func (list *List) Unblock(id int, blockers ...int) error {
+	i, err := list.IndexOf(id)
+	if err != nil {
+		return err
+	}
+
+	ls := list.Items
+	ls[i].BlockedBy = removeIDs(ls[i].BlockedBy, blockers...)
+
+	return nil
+}
+
+// OpenBlockers returns the IDs of the items not done yet
+// blocking the to-do item with the given ID
+func (list *List) OpenBlockers(id int) ([]int, error) {
+	i, err := list.IndexOf(id)
+	if err != nil {
+		return nil, err
+	}
+
+	var open []int
+	for _, b := range list.Items[i].BlockedBy {
+		if j, err := list.IndexOf(b); err == nil && !list.Items[j].Done {
+			open = append(open, b)
+		}
+	}
+	return open, nil
+}
+
+// Subtasks returns the IDs of the direct subtasks of the
+// to-do item with the given ID, in list order
+func (list *List) Subtasks(id int) []int {
+	var ids []int
+	for _, v := range list.Items {
+		if v.Parent == id {
+			ids = append(ids, v.ID)
+		}
+	}
+	return ids
+}
+
+func (i item) isBlockedBy(id int) bool {
+	for _, b := range i.BlockedBy {
+		if b == id {
+			return true
+		}
+	}
+	return false
+}
+
+// parentOf returns the parent ID of the item with the given ID,
+// or 0 for top-level and missing items
+func (list *List) parentOf(id int) int {
+	i, err := list.IndexOf(id)
+	if err != nil {
+		return 0
+	}
+	return list.Items[i].Parent
+}
+
+// blocks reports whether the item with the given ID blocks
+// the item with the other ID, directly or through other items
+func (list *List) blocks(id, other int) bool {
+	seen := map[int]bool{}
+	var visit func(int) bool
+	visit = func(n int) bool {
+		if n == id {
+			return true
+		}
+		if seen[n] {
+			return false
+		}
+		seen[n] = true
+
+		i, err := list.IndexOf(n)
+		if err != nil {
+			return false
+		}
+		for _, b := range list.Items[i].BlockedBy {
+			if visit(b) {
+				return true
+			}
+		}
+		return false
+	}
+	return visit(other)
+}
+
+// unlink removes the references to the item with the given ID,
+// which is about to be deleted. Its subtasks move up to its parent
+func (list *List) unlink(id int) {
+	ls := list.Items
+	parent := list.parentOf(id)
+	for i := range ls {
+		if ls[i].Parent == id {
+			ls[i].Parent = parent
+		}
+		if ls[i].isBlockedBy(id) {
+			ls[i].BlockedBy = removeIDs(ls[i].BlockedBy, id)
+		}
+	}
+}
+
+// unlinkLists removes the parents and blockers in another named
+// list than their items, left by moving items between lists
+func (list *List) unlinkLists() {
+	names := map[int]string{}
+	for _, v := range list.Items {
+		names[v.ID] = v.listName()
+	}
+
+	ls := list.Items
+	for i := range ls {
+		name := ls[i].listName()
+		if p, ok := names[ls[i].Parent]; ok && p != name {
+			ls[i].Parent = 0
+		}
+		var other []int
+		for _, b := range ls[i].BlockedBy {
+			if n, ok := names[b]; ok && n != name {
+				other = append(other, b)
+			}
+		}
+		if len(other) > 0 {
+			ls[i].BlockedBy = removeIDs(ls[i].BlockedBy, other...)
+		}
+	}
+}
+
+func removeIDs(ids []int, remove ...int) []int {
+	var kept []int
+	for _, id := range ids {
+		found := false
+		for _, r := range remove {
+			if id == r {
+				found = true
+				break
+			}
+		}
+		if !found {
+			kept = append(kept, id)
+		}
+	}
+	return kept
+}
+
+// tree returns the items of the list with their depth in the tree of
+// subtasks. Subtasks follow their parent, keeping the list order among
+// siblings. Items whose parent isn't in the list are top-level
+func (list *List) tree() ([]item, []int) {
+	ls := list.Items
+	inList := map[int]bool{}
+	for _, v := range ls {
+		inList[v.ID] = true
+	}
+	children := map[int][]item{}
+	var roots []item
+	for _, v := range ls {
+		if v.Parent != 0 && inList[v.Parent] {
+			children[v.Parent] = append(children[v.Parent], v)
+			continue
+		}
+		roots = append(roots, v)
+	}
+
+	items := make([]item, 0, len(ls))
+	depths := make([]int, 0, len(ls))
+	seen := map[int]bool{}
+	var walk func([]item, int)
+	walk = func(level []item, depth int) {
+		for _, v := range level {
+			if seen[v.ID] {
+				continue
+			}
+			seen[v.ID] = true
+			items = append(items, v)
+			depths = append(depths, depth)
+			walk(children[v.ID], depth+1)
+		}
+	}
+	walk(roots, 0)
+
+	// items in a loop of parents, only possible in files edited
+	// by hand, aren't reachable from the top-level items
+	for _, v := range ls {
+		walk([]item{v}, 0)
+	}
+	return items, depths
+}
+
+// blockedWord formats the blockers of an item for String
+func (i item) blockedWord() string {
+	if len(i.BlockedBy) == 0 {
+		return ""
+	}
+	ids := make([]string, len(i.BlockedBy))
+	for k, b := range i.BlockedBy {
+		ids[k] = fmt.Sprint(b)
+	}
+	return " blocked:" + strings.Join(ids, ",")
+}
@@ -0,0 +1,144 @@
+package todo_test
+
+import (
+	"errors"
+	"testing"
+	"todo"
+)
+
+func TestList_Subtasks(t *testing.T) {
+	ls := todo.List{}
+
+	release := ls.Add("Release")
+	docs := ls.Add("Write docs")
+	tag := ls.Add("Tag version")
+	ls.Add("Buy milk")
+	notes := ls.Add("Release notes")
+
+	for _, id := range []int{docs, tag} {
+		if err := ls.SetParent(id, release); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := ls.SetParent(notes, docs); err != nil {
+		t.Fatal(err)
+	}
+
+	exp := "  1: Release\n" +
+		"    2: Write docs\n" +
+		"      5: Release notes\n" +
+		"    3: Tag version\n" +
+		"  4: Buy milk\n"
+	if res := ls.String(); res != exp {
+		t.Errorf("Expected:\n%s\ngot:\n%s", exp, res)
+	}
+
+	if ids := ls.Subtasks(release); len(ids) != 2 || ids[0] != docs || ids[1] != tag {
+		t.Errorf("Expected subtasks [%d %d], got %v instead.", docs, tag, ids)
+	}
+
+	if err := ls.SetParent(release, notes); err == nil {
+		t.Error("Expected error making an item a subtask of its own subtask, got nil")
+	}
+	if err := ls.SetParent(docs, 10); err == nil {
+		t.Error("Expected error for missing parent, got nil")
+	}
+
+	// subtasks of a deleted item move up to its parent
+	if err := ls.Delete(docs); err != nil {
+		t.Fatal(err)
+	}
+	if ids := ls.Subtasks(release); len(ids) != 2 || ids[1] != notes {
+		t.Errorf("Expected subtasks [%d %d], got %v instead.", tag, notes, ids)
+	}
+}
+
+func TestList_Block(t *testing.T) {
+	ls := todo.List{}
+
+	design := ls.Add("Design")
+	build := ls.Add("Build")
+	ship := ls.Add("Ship")
+
+	if err := ls.Block(build, design); err != nil {
+		t.Fatal(err)
+	}
+	if err := ls.Block(ship, build, design); err != nil {
+		t.Fatal(err)
+	}
+	if err := ls.Block(design, ship); err == nil {
+		t.Error("Expected error for a loop of blockers, got nil")
+	}
+	if err := ls.Block(design, design); err == nil {
+		t.Error("Expected error for an item blocking itself, got nil")
+	}
+
+	if err := ls.Complete(ship); !errors.Is(err, todo.ErrBlocked) {
+		t.Errorf("Expected ErrBlocked, got %v instead.", err)
+	}
+	if ls.Items[2].Done {
+		t.Error("Blocked item should not be completed")
+	}
+	last := todo.List{Items: ls.Items[2:]}
+	if exp := "  3: Ship blocked:1,2\n"; last.String() != exp {
+		t.Errorf("Expected %q, got %q instead.", exp, last.String())
+	}
+
+	ls.Complete(design)
+	open, err := ls.OpenBlockers(ship)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(open) != 1 || open[0] != build {
+		t.Errorf("Expected open blockers [%d], got %v instead.", build, open)
+	}
+
+	// deleting a blocker unblocks the items it blocked
+	ls.Delete(build)
+	if err := ls.Complete(ship); err != nil {
+		t.Errorf("Expected no error, got %v instead.", err)
+	}
+
+	if err := ls.Unblock(ship, design); err != nil {
+		t.Fatal(err)
+	}
+	if len(ls.Items[1].BlockedBy) != 0 {
+		t.Errorf("Expected no blockers, got %v instead.", ls.Items[1].BlockedBy)
+	}
+}
+
+func TestList_LinksAcrossLists(t *testing.T) {
+	ls := todo.List{}
+
+	home := ls.Add("Paint the fence")
+	work := ls.Add("Write the report")
+	outline := ls.Add("Outline the report")
+	if err := ls.MoveTo(work, "work"); err != nil {
+		t.Fatal(err)
+	}
+	if err := ls.MoveTo(outline, "work"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ls.SetParent(home, work); err == nil {
+		t.Error("Expected error for a parent in another list, got nil")
+	}
+	if err := ls.Block(home, work); err == nil {
+		t.Error("Expected error for a blocker in another list, got nil")
+	}
+
+	// moving an item leaves its parent and blockers behind
+	if err := ls.SetParent(outline, work); err != nil {
+		t.Fatal(err)
+	}
+	if err := ls.Block(work, outline); err != nil {
+		t.Fatal(err)
+	}
+	if err := ls.MoveTo(outline, todo.DefaultList); err != nil {
+		t.Fatal(err)
+	}
+	if ls.Items[2].Parent != 0 || len(ls.Items[1].BlockedBy) != 0 {
+		t.Errorf("Expected no links across lists, got parent %d and blockers %v instead.",
+			ls.Items[2].Parent, ls.Items[1].BlockedBy)
+	}
+}
@@ -0,0 +1,121 @@
+package todo
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// DefaultList is the name of the list holding the items
+// added without a list name
+const DefaultList = "default"
+
+// ListSummary counts the items of a named list
+type ListSummary struct {
+	Name  string
+	Items int
+	Done  int
+}
+
+// Named returns the items of the named list, in list order.
+// The items keep their IDs, which are unique across lists
+func (list *List) Named(name string) List {
+	name = normalizeListName(name)
+	named := List{next: list.next}
+	for _, v := range list.Items {
+		if v.ListName == name {
+			named.Items = append(named.Items, v)
+		}
+	}
+	return named
+}
+
+// Lists returns the names of the lists with items and their counts,
+// sorted by name. The default list is always included
+func (list *List) Lists() []ListSummary {
+	counts := map[string]*ListSummary{
+		DefaultList: {Name: DefaultList},
+	}
+	for _, v := range list.Items {
+		name := v.listName()
+		s, ok := counts[name]
+		if !ok {
+			s = &ListSummary{Name: name}
+			counts[name] = s
+		}
+		s.Items++
+		if v.Done {
+			s.Done++
+		}
+	}
+
+	lists := make([]ListSummary, 0, len(counts))
+	for _, s := range counts {
+		lists = append(lists, *s)
+	}
+	sort.Slice(lists, func(i, j int) bool {
+		return lists[i].Name < lists[j].Name
+	})
+	return lists
+}
+
+// MoveTo moves the to-do item with the given ID, and its subtasks,
+// to the named list. It's no longer a subtask of an item left in the
+// other list, and the blockers between both lists are removed
+func (list *List) MoveTo(id int, name string) error {
+	if _, err := list.IndexOf(id); err != nil {
+		return err
+	}
+	if err := ValidateListName(name); err != nil {
+		return err
+	}
+
+	list.moveTo(id, normalizeListName(name), map[int]bool{})
+	list.unlinkLists()
+	return nil
+}
+
+// moveTo moves the item with the given ID and its subtasks, skipping
+// the ones seen already, as edited files may have cyclic parents
+func (list *List) moveTo(id int, name string, seen map[int]bool) {
+	if seen[id] {
+		return
+	}
+	seen[id] = true
+
+	if i, err := list.IndexOf(id); err == nil {
+		list.Items[i].ListName = name
+	}
+	for _, sub := range list.Subtasks(id) {
+		list.moveTo(sub, name, seen)
+	}
+}
+
+// ValidateListName checks a list name can be used in
+// commands and URLs: it can't be blank or have spaces or slashes
+func ValidateListName(name string) error {
+	if strings.TrimSpace(name) == "" {
+		return fmt.Errorf("list name cannot be blank")
+	}
+	if strings.ContainsAny(name, "/ \t\n") {
+		return fmt.Errorf("invalid list name %q: cannot have spaces or slashes", name)
+	}
+	return nil
+}
+
+// listName returns the name of the list the item is in
+func (i item) listName() string {
+	if i.ListName == "" {
+		return DefaultList
+	}
+	return i.ListName
+}
+
+// normalizeListName returns the name stored in items for the
+// named list, empty for the default list as in older files
+func normalizeListName(name string) string {
+	if name == DefaultList {
+		return ""
+	}
+	return name
+}
@@ -0,0 +1,93 @@
+package todo_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"todo"
+)
+
+func TestList_NamedLists(t *testing.T) {
+	ls := todo.List{}
+
+	ls.Add("Buy milk")
+	report := ls.Add("Write report")
+	slides := ls.Add("Prepare slides")
+	ls.Add("Call mom")
+	notes := ls.Add("Speaker notes")
+	ls.SetParent(notes, slides)
+
+	for _, id := range []int{report, slides} {
+		if err := ls.MoveTo(id, "work"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ls.Complete(report)
+
+	work := ls.Named("work")
+	if len(work.Items) != 3 || work.Items[2].ID != notes {
+		t.Fatalf("Expected the subtask to move along, got:\n%s", work.String())
+	}
+	if def := ls.Named(todo.DefaultList); len(def.Items) != 2 {
+		t.Errorf("Expected 2 items in the default list, got %d instead.", len(def.Items))
+	}
+
+	exp := []todo.ListSummary{
+		{Name: todo.DefaultList, Items: 2},
+		{Name: "work", Items: 3, Done: 1},
+	}
+	lists := ls.Lists()
+	if len(lists) != len(exp) {
+		t.Fatalf("Expected %v, got %v instead.", exp, lists)
+	}
+	for i := range exp {
+		if lists[i] != exp[i] {
+			t.Errorf("Expected %v, got %v instead.", exp[i], lists[i])
+		}
+	}
+
+	// positions are relative to the named list
+	if err := ls.Move(slides, 1); err != nil {
+		t.Fatal(err)
+	}
+	if work := ls.Named("work"); work.Items[0].ID != slides || work.Items[1].ID != report {
+		t.Errorf("Expected item %d first, got:\n%s", slides, work.String())
+	}
+	if err := ls.Move(slides, 4); err == nil {
+		t.Error("Expected error moving past the end of the named list, got nil")
+	}
+
+	if err := ls.MoveTo(report, "my list"); err == nil {
+		t.Error("Expected error for a list name with spaces, got nil")
+	}
+	if err := ls.MoveTo(report, todo.DefaultList); err != nil {
+		t.Fatal(err)
+	}
+	if def := ls.Named(""); len(def.Items) != 3 {
+		t.Errorf("Expected 3 items in the default list, got %d instead.", len(def.Items))
+	}
+}
+
+func TestList_MoveToCyclicParents(t *testing.T) {
+	// SetParent refuses cycles, but a file edited by hand may have them
+	filename := filepath.Join(t.TempDir(), ".todo.json")
+	data := `[{"ID":1,"Task":"Task 1","Parent":3},{"ID":2,"Task":"Task 2","Parent":1},` +
+		`{"ID":3,"Task":"Task 3","Parent":2},{"ID":4,"Task":"Task 4"}]`
+	if err := os.WriteFile(filename, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	ls := todo.List{}
+	if err := ls.Get(filename); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ls.MoveTo(1, "work"); err != nil {
+		t.Fatal(err)
+	}
+	if work := ls.Named("work"); len(work.Items) != 3 {
+		t.Errorf("Expected the 3 items of the cycle in the work list, got:\n%s", work.String())
+	}
+	if def := ls.Named(todo.DefaultList); len(def.Items) != 1 || def.Items[0].ID != 4 {
+		t.Errorf("Expected item 4 left in the default list, got:\n%s", def.String())
+	}
+}
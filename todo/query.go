@@ -0,0 +1,276 @@
+package todo
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Query selects and orders the items of a list.
+// A query is made of terms, all of which must match an item:
+//
+//	done:true|false     completion status
+//	tag:NAME            items tagged with NAME
+//	priority:A          items with the given priority
+//	due<DATE            due date compared to DATE (YYYY-MM-DD),
+//	created>=DATE       using one of : < <= > >=
+//	sort:KEY[,KEY]      order by id, task, created, due or priority,
+//	                    a leading - reverses the order
+//	TEXT or "SOME TEXT" task text contains TEXT, ignoring case
+type Query struct {
+	filters []func(item) bool
+	sortBy  []sortKey
+}
+
+type sortKey struct {
+	name string
+	desc bool
+}
+
+// ParseQuery parses a query expression, where terms are
+// separated by spaces and double quotes group words
+func ParseQuery(expr string) (*Query, error) {
+	terms, err := splitTerms(expr)
+	if err != nil {
+		return nil, err
+	}
+	return NewQuery(terms...)
+}
+
+// NewQuery creates a query from terms that are already split,
+// such as command line arguments
+func NewQuery(terms ...string) (*Query, error) {
+	q := &Query{}
+	for _, t := range terms {
+		if err := q.addTerm(t); err != nil {
+			return nil, err
+		}
+	}
+	return q, nil
+}
+
+// Filter returns the items of the list matching the query,
+// in the order requested by the query. The list isn't modified
+func (list *List) Filter(q *Query) List {
+	selected := List{next: list.next}
+	for _, v := range list.Items {
+		if q.match(v) {
+			selected.Items = append(selected.Items, v)
+		}
+	}
+
+	if len(q.sortBy) > 0 {
+		ls := selected.Items
+		sort.SliceStable(ls, func(i, j int) bool {
+			return q.less(ls[i], ls[j])
+		})
+	}
+
+	return selected
+}
+
+func (q *Query) match(i item) bool {
+	for _, f := range q.filters {
+		if !f(i) {
+			return false
+		}
+	}
+	return true
+}
+
+// less compares two items using the query sort keys in order,
+// moving on to the next key when they're equal
+func (q *Query) less(a, b item) bool {
+	for _, k := range q.sortBy {
+		c := compareBy(k.name, a, b)
+		if c == 0 {
+			continue
+		}
+		if k.desc {
+			return c > 0
+		}
+		return c < 0
+	}
+	return false
+}
+
+// compareBy returns -1, 0 or 1 comparing the given field of two items.
+// Missing due dates and priorities sort after present ones
+func compareBy(key string, a, b item) int {
+	switch key {
+	case "id":
+		switch {
+		case a.ID < b.ID:
+			return -1
+		case a.ID > b.ID:
+			return 1
+		}
+		return 0
+	case "task":
+		return strings.Compare(strings.ToLower(a.Task), strings.ToLower(b.Task))
+	case "created":
+		return compareTimes(a.CreateAt, b.CreateAt)
+	case "due":
+		switch {
+		case a.Due.IsZero() && b.Due.IsZero():
+			return 0
+		case a.Due.IsZero():
+			return 1
+		case b.Due.IsZero():
+			return -1
+		}
+		return compareTimes(a.Due, b.Due)
+	case "priority":
+		switch {
+		case a.Priority == b.Priority:
+			return 0
+		case a.Priority == "":
+			return 1
+		case b.Priority == "":
+			return -1
+		case a.Priority < b.Priority:
+			return -1
+		}
+		return 1
+	}
+	return 0
+}
+
+func compareTimes(a, b time.Time) int {
+	switch {
+	case a.Before(b):
+		return -1
+	case a.After(b):
+		return 1
+	}
+	return 0
+}
+
+func (q *Query) addTerm(term string) error {
+	for _, field := range []string{"due", "created"} {
+		if strings.HasPrefix(term, field) && len(term) > len(field) &&
+			strings.ContainsRune(":<>", rune(term[len(field)])) {
+			return q.addDateTerm(field, term[len(field):])
+		}
+	}
+
+	kv := strings.SplitN(term, ":", 2)
+	if len(kv) != 2 {
+		q.addText(term)
+		return nil
+	}
+
+	value := kv[1]
+	switch strings.ToLower(kv[0]) {
+	case "done":
+		done, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid query term %q: %w", term, err)
+		}
+		q.filters = append(q.filters, func(i item) bool {
+			return i.Done == done
+		})
+	case "tag":
+		q.filters = append(q.filters, func(i item) bool {
+			return i.HasTag(value)
+		})
+	case "priority":
+		q.filters = append(q.filters, func(i item) bool {
+			return strings.EqualFold(i.Priority, value)
+		})
+	case "sort":
+		for _, k := range strings.Split(value, ",") {
+			sk := sortKey{name: strings.TrimPrefix(k, "-"), desc: strings.HasPrefix(k, "-")}
+			switch sk.name {
+			case "id", "task", "created", "due", "priority":
+			default:
+				return fmt.Errorf("invalid sort key %q: must be id, task, created, due or priority", k)
+			}
+			q.sortBy = append(q.sortBy, sk)
+		}
+	default:
+		q.addText(term)
+	}
+	return nil
+}
+
+func (q *Query) addText(text string) {
+	text = strings.ToLower(text)
+	q.filters = append(q.filters, func(i item) bool {
+		return strings.Contains(strings.ToLower(i.Task), text)
+	})
+}
+
+// addDateTerm adds a filter comparing the date of the given field with
+// the date in expr, such as "<2026-11-01". Items without a due date never
+// match a due filter
+func (q *Query) addDateTerm(field, expr string) error {
+	op := strings.TrimRight(expr, "0123456789-")
+	switch op {
+	case ":", "<", "<=", ">", ">=":
+	default:
+		return fmt.Errorf("invalid query term %q: unknown operator %q", field+expr, op)
+	}
+
+	day, err := time.ParseInLocation(DateFormat, expr[len(op):], time.Local)
+	if err != nil {
+		return fmt.Errorf("invalid query term %q: %w", field+expr, err)
+	}
+	next := day.AddDate(0, 0, 1)
+
+	q.filters = append(q.filters, func(i item) bool {
+		t := i.CreateAt
+		if field == "due" {
+			t = i.Due
+			if t.IsZero() {
+				return false
+			}
+		}
+
+		switch op {
+		case "<":
+			return t.Before(day)
+		case "<=":
+			return t.Before(next)
+		case ">":
+			return !t.Before(next)
+		case ">=":
+			return !t.Before(day)
+		}
+		return !t.Before(day) && t.Before(next)
+	})
+	return nil
+}
+
+// splitTerms splits expr on spaces, keeping the words
+// enclosed in double quotes together
+func splitTerms(expr string) ([]string, error) {
+	var (
+		terms   []string
+		current strings.Builder
+		quoted  bool
+	)
+	for _, r := range expr {
+		switch {
+		case r == '"':
+			quoted = !quoted
+		case unicode.IsSpace(r) && !quoted:
+			if current.Len() > 0 {
+				terms = append(terms, current.String())
+				current.Reset()
+			}
+		default:
+			current.WriteRune(r)
+		}
+	}
+	if quoted {
+		return nil, fmt.Errorf("invalid query %q: unterminated quote", expr)
+	}
+	if current.Len() > 0 {
+		terms = append(terms, current.String())
+	}
+	return terms, nil
+}
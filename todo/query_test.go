@@ -0,0 +1,94 @@
+package todo_test
+
+import (
+	"testing"
+	"time"
+	"todo"
+)
+
+func TestList_Filter(t *testing.T) {
+	ls := todo.List{}
+
+	day := func(d int) time.Time {
+		return time.Date(2026, time.November, d, 0, 0, 0, 0, time.Local)
+	}
+
+	id := ls.Add("Write report")
+	ls.SetDue(id, day(10))
+	ls.SetPriority(id, "B")
+	ls.AddTags(id, "work")
+
+	id = ls.Add("Buy milk")
+	ls.AddTags(id, "home")
+
+	id = ls.Add("Review the weekly report")
+	ls.SetDue(id, day(1))
+	ls.SetPriority(id, "A")
+	ls.AddTags(id, "work")
+	ls.Complete(id)
+
+	id = ls.Add("Plan sprint")
+	ls.SetDue(id, day(5))
+	ls.AddTags(id, "work")
+
+	for i := range ls.Items {
+		ls.Items[i].CreateAt = day(i + 1)
+	}
+
+	testCases := []struct {
+		name   string
+		query  string
+		expIDs []int
+	}{
+		{name: "Empty", query: "", expIDs: []int{1, 2, 3, 4}},
+		{name: "NotDone", query: "done:false", expIDs: []int{1, 2, 4}},
+		{name: "Tag", query: "tag:work done:false", expIDs: []int{1, 4}},
+		{name: "DueBefore", query: "due<2026-11-06", expIDs: []int{3, 4}},
+		{name: "DueOn", query: "due:2026-11-05", expIDs: []int{4}},
+		{name: "DueAfter", query: "due>=2026-11-05", expIDs: []int{1, 4}},
+		{name: "Text", query: "REPORT", expIDs: []int{1, 3}},
+		{name: "QuotedText", query: `"weekly report"`, expIDs: []int{3}},
+		{name: "SortDue", query: "tag:work sort:due", expIDs: []int{3, 4, 1}},
+		{name: "SortPriority", query: "sort:priority", expIDs: []int{3, 1, 2, 4}},
+		{name: "SortCreatedDesc", query: "sort:-created", expIDs: []int{4, 3, 2, 1}},
+		{name: "SortTask", query: "sort:task", expIDs: []int{2, 4, 3, 1}},
+		{name: "SortDoneIDDesc", query: "done:false sort:-id", expIDs: []int{4, 2, 1}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			q, err := todo.ParseQuery(tc.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			res := ls.Filter(q)
+			if len(res.Items) != len(tc.expIDs) {
+				t.Fatalf("Expected %d items, got %d instead:\n%s",
+					len(tc.expIDs), len(res.Items), res.String())
+			}
+			for i, v := range res.Items {
+				if v.ID != tc.expIDs[i] {
+					t.Errorf("Expected item %d at position %d, got %d instead.",
+						tc.expIDs[i], i, v.ID)
+				}
+			}
+		})
+	}
+}
+
+func TestParseQuery_Errors(t *testing.T) {
+	queries := []string{
+		"done:maybe",
+		"due<tomorrow",
+		"due<=>2026-11-01",
+		"sort:name",
+		`"unterminated`,
+	}
+
+	for _, q := range queries {
+		if _, err := todo.ParseQuery(q); err == nil {
+			t.Errorf("Expected error parsing %q, got nil", q)
+		}
+	}
+}
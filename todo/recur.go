@@ -0,0 +1,220 @@
+package todo
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Recurrence kinds
+const (
+	RecurDaily    = "daily"
+	RecurWeekdays = "weekdays"
+	RecurWeekly   = "weekly"
+	RecurMonthly  = "monthly"
+)
+
+var weekdayNames = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// Recurrence is a rule for repeating a task, written as:
+//
+//	daily             every day
+//	weekdays          Monday to Friday
+//	weekly            every week on the same day
+//	weekly:mon,thu    every week on the given days
+//	monthly           every month on the same day
+//	monthly:15        every month on the given day
+type Recurrence struct {
+	Kind string
+	// Days are the days of the week for weekly rules
+	Days []time.Weekday
+	// Day is the day of the month for monthly rules
+	Day int
+}
+
+// ParseRecurrence parses a recurrence rule
+func ParseRecurrence(rule string) (Recurrence, error) {
+	kv := strings.SplitN(strings.ToLower(strings.TrimSpace(rule)), ":", 2)
+	r := Recurrence{Kind: kv[0]}
+
+	switch r.Kind {
+	case RecurDaily, RecurWeekdays:
+		if len(kv) == 2 {
+			return Recurrence{}, fmt.Errorf("invalid recurrence %q: %s takes no options", rule, r.Kind)
+		}
+	case RecurWeekly:
+		if len(kv) == 1 {
+			break
+		}
+		for _, d := range strings.Split(kv[1], ",") {
+			wd, ok := weekdayNames[d]
+			if !ok {
+				return Recurrence{}, fmt.Errorf("invalid recurrence %q: unknown day %q", rule, d)
+			}
+			r.Days = append(r.Days, wd)
+		}
+	case RecurMonthly:
+		if len(kv) == 1 {
+			break
+		}
+		day, err := strconv.Atoi(kv[1])
+		if err != nil || day < 1 || day > 31 {
+			return Recurrence{}, fmt.Errorf("invalid recurrence %q: day must be from 1 to 31", rule)
+		}
+		r.Day = day
+	default:
+		return Recurrence{}, fmt.Errorf("invalid recurrence %q: must be %s, %s, %s or %s",
+			rule, RecurDaily, RecurWeekdays, RecurWeekly, RecurMonthly)
+	}
+
+	return r, nil
+}
+
+// String returns the rule in the form parsed by ParseRecurrence
+func (r Recurrence) String() string {
+	switch {
+	case len(r.Days) > 0:
+		days := make([]string, len(r.Days))
+		for k, d := range r.Days {
+			days[k] = strings.ToLower(d.String()[:3])
+		}
+		return r.Kind + ":" + strings.Join(days, ",")
+	case r.Day > 0:
+		return fmt.Sprintf("%s:%d", r.Kind, r.Day)
+	}
+	return r.Kind
+}
+
+// Next returns the first day after the day of t matching the rule,
+// at midnight. Monthly rules on days missing from a month, such as
+// the 31st, fall on the last day of that month
+func (r Recurrence) Next(t time.Time) time.Time {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+
+	switch r.Kind {
+	case RecurWeekdays:
+		next := day.AddDate(0, 0, 1)
+		for next.Weekday() == time.Saturday || next.Weekday() == time.Sunday {
+			next = next.AddDate(0, 0, 1)
+		}
+		return next
+	case RecurWeekly:
+		if len(r.Days) == 0 {
+			return day.AddDate(0, 0, 7)
+		}
+		for n := 1; ; n++ {
+			next := day.AddDate(0, 0, n)
+			for _, d := range r.Days {
+				if next.Weekday() == d {
+					return next
+				}
+			}
+		}
+	case RecurMonthly:
+		target := r.Day
+		if target == 0 {
+			target = day.Day()
+		}
+		next := monthDay(day.Year(), day.Month(), target, day.Location())
+		if !next.After(day) {
+			next = monthDay(day.Year(), day.Month()+1, target, day.Location())
+		}
+		return next
+	}
+	return day.AddDate(0, 0, 1)
+}
+
+// monthDay returns the given day of a month, or
+// the last day of the month if it's shorter
+func monthDay(year int, month time.Month, day int, loc *time.Location) time.Time {
+	last := time.Date(year, month+1, 0, 0, 0, 0, 0, loc).Day()
+	if day > last {
+		day = last
+	}
+	return time.Date(year, month, day, 0, 0, 0, 0, loc)
+}
+
+// SetRecurrence makes the to-do item with the given ID repeat following
+// rule. Rules repeating on the same day use the day of the due date.
+// Items repeating without a due date are due on the first day
+// matching the rule, starting today. An empty rule stops the repetition
+func (list *List) SetRecurrence(id int, rule string) error {
+	i, err := list.IndexOf(id)
+	if err != nil {
+		return err
+	}
+
+	ls := list.Items
+	if rule == "" {
+		ls[i].Recur = ""
+		return nil
+	}
+
+	r, err := ParseRecurrence(rule)
+	if err != nil {
+		return err
+	}
+
+	// pin rules repeating on the same day to the due date, or today
+	now := time.Now()
+	start := ls[i].Due
+	if start.IsZero() {
+		start = now
+	}
+	if r.Kind == RecurWeekly && len(r.Days) == 0 {
+		r.Days = []time.Weekday{start.Weekday()}
+	}
+	if r.Kind == RecurMonthly && r.Day == 0 {
+		r.Day = start.Day()
+	}
+
+	ls[i].Recur = r.String()
+	if ls[i].Due.IsZero() {
+		ls[i].Due = r.Next(now.AddDate(0, 0, -1))
+	}
+
+	return nil
+}
+
+// scheduleNext adds the next occurrence of the recurring item at index i,
+// due on the first day matching its rule after both its due date and
+// today, at the same time
+func (list *List) scheduleNext(i int) error {
+	r, err := ParseRecurrence(list.Items[i].Recur)
+	if err != nil {
+		return err
+	}
+
+	v := list.Items[i]
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	next := today
+	if v.Due.After(today) {
+		next = v.Due
+	}
+
+	// keep the due time, if any
+	dueDay := time.Date(v.Due.Year(), v.Due.Month(), v.Due.Day(), 0, 0, 0, 0, v.Due.Location())
+	next = r.Next(next).Add(v.Due.Sub(dueDay))
+
+	v.ID = list.nextID()
+	v.Done = false
+	v.CreateAt = now
+	v.CompletedAt = time.Time{}
+	v.Due = next
+	v.Tags = append([]string(nil), v.Tags...)
+	v.BlockedBy = append([]int(nil), v.BlockedBy...)
+	list.Items = append(list.Items, v)
+
+	return nil
+}
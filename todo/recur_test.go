@@ -0,0 +1,127 @@
+package todo_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"todo"
+)
+
+func TestRecurrence_Next(t *testing.T) {
+	// Friday
+	from := time.Date(2026, time.October, 30, 15, 30, 0, 0, time.Local)
+
+	testCases := []struct {
+		rule string
+		exp  string
+	}{
+		{rule: "daily", exp: "2026-10-31"},
+		{rule: "weekdays", exp: "2026-11-02"},
+		{rule: "weekly", exp: "2026-11-06"},
+		{rule: "weekly:mon,thu", exp: "2026-11-02"},
+		{rule: "Weekly:Sat", exp: "2026-10-31"},
+		{rule: "monthly", exp: "2026-11-30"},
+		{rule: "monthly:15", exp: "2026-11-15"},
+		{rule: "monthly:31", exp: "2026-10-31"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.rule, func(t *testing.T) {
+			r, err := todo.ParseRecurrence(tc.rule)
+			if err != nil {
+				t.Fatal(err)
+			}
+			next := r.Next(from).Format(todo.DateFormat)
+			if next != tc.exp {
+				t.Errorf("Expected %s, got %s instead.", tc.exp, next)
+			}
+		})
+	}
+
+	// monthly on the 31st falls on the last day of shorter months
+	r, _ := todo.ParseRecurrence("monthly:31")
+	next := r.Next(time.Date(2026, time.October, 31, 0, 0, 0, 0, time.Local))
+	if exp := "2026-11-30"; next.Format(todo.DateFormat) != exp {
+		t.Errorf("Expected %s, got %s instead.", exp, next.Format(todo.DateFormat))
+	}
+}
+
+func TestParseRecurrence_Errors(t *testing.T) {
+	for _, rule := range []string{"yearly", "daily:2", "weekly:someday", "monthly:32", ""} {
+		if _, err := todo.ParseRecurrence(rule); err == nil {
+			t.Errorf("Expected error parsing %q, got nil", rule)
+		}
+	}
+}
+
+func TestList_CompleteRecurring(t *testing.T) {
+	ls := todo.List{}
+
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+
+	id := ls.Add("Stand-up prep")
+	ls.AddTags(id, "work")
+	if err := ls.SetRecurrence(id, "daily"); err != nil {
+		t.Fatal(err)
+	}
+	if !ls.Items[0].Due.Equal(today) {
+		t.Errorf("Expected first occurrence due %s, got %s instead.", today, ls.Items[0].Due)
+	}
+
+	if err := ls.Complete(id); err != nil {
+		t.Fatal(err)
+	}
+	if len(ls.Items) != 2 {
+		t.Fatalf("Expected next occurrence to be added, got %d items.", len(ls.Items))
+	}
+	if !ls.Items[0].Done {
+		t.Error("Expected first occurrence to be completed")
+	}
+
+	next := ls.Items[1]
+	if next.Done || next.ID != 2 || next.Recur != "daily" || !next.HasTag("work") {
+		t.Errorf("Unexpected next occurrence %+v", next)
+	}
+	if exp := today.AddDate(0, 0, 1); !next.Due.Equal(exp) {
+		t.Errorf("Expected next occurrence due %s, got %s instead.", exp, next.Due)
+	}
+
+	// completing again doesn't add another occurrence
+	ls.Complete(id)
+	if len(ls.Items) != 2 {
+		t.Errorf("Expected 2 items, got %d instead.", len(ls.Items))
+	}
+
+	if !strings.Contains(ls.String(), " rec:daily") {
+		t.Errorf("Expected recurrence in %q", ls.String())
+	}
+}
+
+func TestList_SetRecurrencePinsDay(t *testing.T) {
+	ls := todo.List{}
+
+	id := ls.Add("Monthly report")
+	ls.SetDue(id, time.Date(2026, time.January, 31, 0, 0, 0, 0, time.Local))
+	if err := ls.SetRecurrence(id, "monthly"); err != nil {
+		t.Fatal(err)
+	}
+	if ls.Items[0].Recur != "monthly:31" {
+		t.Errorf("Expected %q, got %q instead.", "monthly:31", ls.Items[0].Recur)
+	}
+}
+
+func TestList_CompleteRecurringKeepsTime(t *testing.T) {
+	ls := todo.List{}
+
+	due := time.Now().AddDate(0, 0, 3)
+	due = time.Date(due.Year(), due.Month(), due.Day(), 9, 30, 0, 0, time.Local)
+	id := ls.Add("Team meeting")
+	ls.SetDue(id, due)
+	ls.SetRecurrence(id, "daily")
+	ls.Complete(id)
+
+	if exp := due.AddDate(0, 0, 1); !ls.Items[1].Due.Equal(exp) {
+		t.Errorf("Expected next occurrence due %s, got %s instead.", exp, ls.Items[1].Due)
+	}
+}
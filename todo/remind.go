@@ -0,0 +1,43 @@
+package todo
+
+import (
+	"fmt"
+	"time"
+)
+
+// Reminder is a notice about an open item coming due or overdue
+type Reminder struct {
+	ID   int
+	Task string
+	Due  time.Time
+	// Overdue is how long ago the item was due,
+	// negative when it isn't due yet
+	Overdue time.Duration
+}
+
+// Reminders returns the reminders for the open items due before
+// now plus ahead, in list order. Items due on a date without
+// a time are due at the start of that day
+func (list *List) Reminders(now time.Time, ahead time.Duration) []Reminder {
+	var reminders []Reminder
+	for _, v := range list.Items {
+		if v.Done || v.Due.IsZero() || v.Due.After(now.Add(ahead)) {
+			continue
+		}
+		reminders = append(reminders, Reminder{
+			ID:      v.ID,
+			Task:    v.Task,
+			Due:     v.Due,
+			Overdue: now.Sub(v.Due),
+		})
+	}
+	return reminders
+}
+
+// String describes the reminder in one line
+func (r Reminder) String() string {
+	if r.Overdue < 0 {
+		return fmt.Sprintf("%d: %s is due %s", r.ID, r.Task, FormatDue(r.Due))
+	}
+	return fmt.Sprintf("%d: %s is overdue since %s", r.ID, r.Task, FormatDue(r.Due))
+}
@@ -0,0 +1,68 @@
+package todo_test
+
+import (
+	"testing"
+	"time"
+	"todo"
+)
+
+func TestList_Reminders(t *testing.T) {
+	now := time.Date(2026, time.October, 14, 15, 0, 0, 0, time.Local)
+
+	ls := todo.List{}
+	id := ls.Add("Submit report")
+	ls.SetDue(id, now.Add(10*time.Minute))
+	id = ls.Add("Pay rent")
+	ls.SetDue(id, time.Date(2026, time.October, 12, 0, 0, 0, 0, time.Local))
+	id = ls.Add("Book flights")
+	ls.SetDue(id, now.Add(2*time.Hour))
+	id = ls.Add("Renew passport")
+	ls.SetDue(id, now.Add(-time.Hour))
+	ls.Complete(id)
+	ls.Add("Buy milk")
+
+	rs := ls.Reminders(now, 15*time.Minute)
+	if len(rs) != 2 {
+		t.Fatalf("Expected 2 reminders, got %v", rs)
+	}
+	if rs[0].ID != 1 || rs[0].Overdue != -10*time.Minute {
+		t.Errorf("Unexpected reminder %+v", rs[0])
+	}
+	if rs[1].ID != 2 || rs[1].Overdue != 63*time.Hour {
+		t.Errorf("Unexpected reminder %+v", rs[1])
+	}
+
+	exp := []string{
+		"1: Submit report is due 2026-10-14T15:10",
+		"2: Pay rent is overdue since 2026-10-12",
+	}
+	for i, r := range rs {
+		if r.String() != exp[i] {
+			t.Errorf("Expected %q, got %q instead.", exp[i], r.String())
+		}
+	}
+}
+
+func TestParseDue(t *testing.T) {
+	testCases := []struct {
+		in  string
+		exp time.Time
+	}{
+		{"2026-10-14", time.Date(2026, time.October, 14, 0, 0, 0, 0, time.Local)},
+		{"2026-10-14T09:30", time.Date(2026, time.October, 14, 9, 30, 0, 0, time.Local)},
+		{"2026-10-14 09:30", time.Date(2026, time.October, 14, 9, 30, 0, 0, time.Local)},
+	}
+	for _, tc := range testCases {
+		got, err := todo.ParseDue(tc.in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !got.Equal(tc.exp) {
+			t.Errorf("Expected %s, got %s instead.", tc.exp, got)
+		}
+	}
+
+	if _, err := todo.ParseDue("tomorrow"); err == nil {
+		t.Error("Expected error, got nil")
+	}
+}
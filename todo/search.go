@@ -0,0 +1,63 @@
+package todo
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Search modes accepted by Search
+const (
+	SearchSubstring = "substring"
+	SearchWord      = "word"
+	SearchRegexp    = "regexp"
+)
+
+// Search returns the items whose task or notes match pattern, in list
+// order. Matching ignores case and, depending on mode, looks for
+// pattern as a substring, as a whole word or as a regular expression.
+// An empty mode means substring matching
+func (list *List) Search(pattern, mode string) (List, error) {
+	match, err := matcher(pattern, mode)
+	if err != nil {
+		return List{}, err
+	}
+
+	found := List{next: list.next}
+	for _, v := range list.Items {
+		if match(v.Task) || match(v.Notes) {
+			found.Items = append(found.Items, v)
+		}
+	}
+	return found, nil
+}
+
+// matcher returns a function reporting whether a text
+// matches pattern in the given search mode
+func matcher(pattern, mode string) (func(string) bool, error) {
+	if strings.TrimSpace(pattern) == "" {
+		return nil, fmt.Errorf("search pattern cannot be blank")
+	}
+
+	var expr string
+	switch mode {
+	case "", SearchSubstring:
+		p := strings.ToLower(pattern)
+		return func(s string) bool {
+			return strings.Contains(strings.ToLower(s), p)
+		}, nil
+	case SearchWord:
+		expr = `(?i)\b` + regexp.QuoteMeta(pattern) + `\b`
+	case SearchRegexp:
+		expr = `(?i)` + pattern
+	default:
+		return nil, fmt.Errorf("unknown search mode %q: must be %s, %s or %s",
+			mode, SearchSubstring, SearchWord, SearchRegexp)
+	}
+
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid search pattern %q: %w", pattern, err)
+	}
+	return re.MatchString, nil
+}
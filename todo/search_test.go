@@ -0,0 +1,69 @@
+package todo_test
+
+import (
+	"testing"
+	"todo"
+)
+
+func TestList_Search(t *testing.T) {
+	ls := todo.List{}
+
+	ls.Add("Write the weekly Report")
+	id := ls.Add("Buy milk")
+	ls.SetNotes(id, "oat milk, not the reporter's brand")
+	ls.Add("Review reports from Q3")
+	ls.Add("Call mom")
+
+	testCases := []struct {
+		name    string
+		pattern string
+		mode    string
+		expIDs  []int
+	}{
+		{name: "Substring", pattern: "REPORT", expIDs: []int{1, 2, 3}},
+		{name: "SubstringMode", pattern: "milk", mode: todo.SearchSubstring, expIDs: []int{2}},
+		{name: "Word", pattern: "report", mode: todo.SearchWord, expIDs: []int{1}},
+		{name: "WordSpecialChars", pattern: "reporter's", mode: todo.SearchWord, expIDs: []int{2}},
+		{name: "Regexp", pattern: `^(call|buy) `, mode: todo.SearchRegexp, expIDs: []int{2, 4}},
+		{name: "NoMatch", pattern: "dentist", expIDs: []int{}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			res, err := ls.Search(tc.pattern, tc.mode)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(res.Items) != len(tc.expIDs) {
+				t.Fatalf("Expected %d items, got %d instead:\n%s",
+					len(tc.expIDs), len(res.Items), res.String())
+			}
+			for i, v := range res.Items {
+				if v.ID != tc.expIDs[i] {
+					t.Errorf("Expected item %d at position %d, got %d instead.",
+						tc.expIDs[i], i, v.ID)
+				}
+			}
+		})
+	}
+}
+
+func TestList_SearchErrors(t *testing.T) {
+	ls := todo.List{}
+	ls.Add("Buy milk")
+
+	testCases := []struct {
+		pattern string
+		mode    string
+	}{
+		{"", todo.SearchSubstring},
+		{"milk", "fuzzy"},
+		{"(milk", todo.SearchRegexp},
+	}
+
+	for _, tc := range testCases {
+		if _, err := ls.Search(tc.pattern, tc.mode); err == nil {
+			t.Errorf("Expected error searching %q as %q, got nil", tc.pattern, tc.mode)
+		}
+	}
+}
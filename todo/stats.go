@@ -0,0 +1,139 @@
+package todo
+
+import (
+	"encoding/json"
+	"sort"
+	"time"
+)
+
+// Number of days, weeks and oldest open items in the statistics
+const (
+	StatsDays   = 7
+	StatsWeeks  = 4
+	StatsOldest = 5
+)
+
+// Count is the number of items completed in the period starting at Start
+type Count struct {
+	Start time.Time
+	Count int
+}
+
+// Stats summarizes the activity on a list
+type Stats struct {
+	Open int
+	Done int
+	// PerDay are the completions in each of the last StatsDays days
+	// and PerWeek the ones in each of the last StatsWeeks weeks,
+	// starting on Monday. The current period is the last one
+	PerDay  []Count
+	PerWeek []Count
+	// MedianTimeToComplete is the median time between the creation
+	// and the completion of the completed items
+	MedianTimeToComplete time.Duration
+	// OldestOpen are the open items created first, the oldest first
+	OldestOpen List
+}
+
+// Stats computes the statistics of the list at the time now
+func (list *List) Stats(now time.Time) Stats {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	monday := today.AddDate(0, 0, -(int(today.Weekday())+6)%7)
+
+	s := Stats{
+		PerDay:     make([]Count, StatsDays),
+		PerWeek:    make([]Count, StatsWeeks),
+		OldestOpen: List{},
+	}
+	for i := range s.PerDay {
+		s.PerDay[i].Start = today.AddDate(0, 0, i-StatsDays+1)
+	}
+	for i := range s.PerWeek {
+		s.PerWeek[i].Start = monday.AddDate(0, 0, 7*(i-StatsWeeks+1))
+	}
+
+	var durations []time.Duration
+	for _, v := range list.Items {
+		if !v.Done {
+			s.Open++
+			s.OldestOpen.Items = append(s.OldestOpen.Items, v)
+			continue
+		}
+
+		s.Done++
+		if v.CompletedAt.IsZero() {
+			continue
+		}
+		if !v.CreateAt.IsZero() && v.CompletedAt.After(v.CreateAt) {
+			durations = append(durations, v.CompletedAt.Sub(v.CreateAt))
+		}
+		countIn(s.PerDay, v.CompletedAt, today.AddDate(0, 0, 1))
+		countIn(s.PerWeek, v.CompletedAt, monday.AddDate(0, 0, 7))
+	}
+
+	if len(durations) > 0 {
+		sort.Slice(durations, func(i, j int) bool {
+			return durations[i] < durations[j]
+		})
+		m := len(durations) / 2
+		s.MedianTimeToComplete = durations[m]
+		if len(durations)%2 == 0 {
+			s.MedianTimeToComplete = (durations[m-1] + durations[m]) / 2
+		}
+	}
+
+	oldest := s.OldestOpen.Items
+	sort.SliceStable(oldest, func(i, j int) bool {
+		return oldest[i].CreateAt.Before(oldest[j].CreateAt)
+	})
+	if len(oldest) > StatsOldest {
+		s.OldestOpen.Items = oldest[:StatsOldest]
+	}
+
+	return s
+}
+
+// countIn adds t to the period of counts it falls in, where
+// end is the end of the last period
+func countIn(counts []Count, t, end time.Time) {
+	for i := len(counts) - 1; i >= 0; i-- {
+		if !t.Before(counts[i].Start) && t.Before(end) {
+			counts[i].Count++
+			return
+		}
+		end = counts[i].Start
+	}
+}
+
+// MarshalJSON encodes the statistics with the median time
+// to complete in seconds, for use in dashboards
+func (s Stats) MarshalJSON() ([]byte, error) {
+	type count struct {
+		Start string `json:"start"`
+		Count int    `json:"count"`
+	}
+	counts := func(cs []Count) []count {
+		out := make([]count, len(cs))
+		for i, c := range cs {
+			out[i] = count{Start: c.Start.Format(DateFormat), Count: c.Count}
+		}
+		return out
+	}
+
+	resp := struct {
+		Open                        int     `json:"open"`
+		Done                        int     `json:"done"`
+		PerDay                      []count `json:"completedPerDay"`
+		PerWeek                     []count `json:"completedPerWeek"`
+		MedianTimeToCompleteSeconds float64 `json:"medianTimeToCompleteSeconds"`
+		OldestOpen                  List    `json:"oldestOpen"`
+	}{
+		Open:                        s.Open,
+		Done:                        s.Done,
+		PerDay:                      counts(s.PerDay),
+		PerWeek:                     counts(s.PerWeek),
+		MedianTimeToCompleteSeconds: s.MedianTimeToComplete.Seconds(),
+		OldestOpen:                  s.OldestOpen,
+	}
+	return json.Marshal(resp)
+}
@@ -0,0 +1,87 @@
+package todo_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+	"todo"
+)
+
+func TestList_Stats(t *testing.T) {
+	// a Wednesday
+	now := time.Date(2026, time.October, 14, 15, 0, 0, 0, time.Local)
+	at := func(days, hours int) time.Time {
+		return now.AddDate(0, 0, -days).Add(time.Duration(-hours) * time.Hour)
+	}
+
+	ls := todo.List{}
+	items := []struct {
+		created   time.Time
+		completed time.Time
+	}{
+		{created: at(20, 0)},
+		{created: at(3, 0), completed: at(0, 1)},
+		{created: at(2, 0), completed: at(1, 0)},
+		{created: at(30, 0)},
+		{created: at(10, 0), completed: at(9, 0)},
+		{created: at(1, 0)},
+		{created: at(60, 0), completed: at(50, 0)},
+	}
+	for _, v := range items {
+		id := ls.Add("Task")
+		if !v.completed.IsZero() {
+			ls.Complete(id)
+		}
+		ls.Items[len(ls.Items)-1].CreateAt = v.created
+		ls.Items[len(ls.Items)-1].CompletedAt = v.completed
+	}
+
+	s := ls.Stats(now)
+	if s.Open != 3 || s.Done != 4 {
+		t.Errorf("Expected 3 open and 4 done, got %d and %d instead.", s.Open, s.Done)
+	}
+
+	// durations: 71h, 24h, 24h, 240h
+	if exp := 47*time.Hour + 30*time.Minute; s.MedianTimeToComplete != exp {
+		t.Errorf("Expected median %s, got %s instead.", exp, s.MedianTimeToComplete)
+	}
+
+	if len(s.PerDay) != todo.StatsDays {
+		t.Fatalf("Expected %d days, got %d instead.", todo.StatsDays, len(s.PerDay))
+	}
+	last := s.PerDay[todo.StatsDays-1]
+	if !last.Start.Equal(time.Date(2026, time.October, 14, 0, 0, 0, 0, time.Local)) || last.Count != 1 {
+		t.Errorf("Unexpected count for today %+v", last)
+	}
+	if s.PerDay[todo.StatsDays-2].Count != 1 || s.PerDay[0].Count != 0 {
+		t.Errorf("Unexpected counts per day %+v", s.PerDay)
+	}
+
+	// weeks start on Monday 2026-10-12, 10-05, 09-28 and 09-21
+	expWeeks := []int{0, 0, 1, 2}
+	for i, c := range s.PerWeek {
+		if c.Count != expWeeks[i] {
+			t.Errorf("Expected %d completions in week of %s, got %d instead.",
+				expWeeks[i], c.Start.Format(todo.DateFormat), c.Count)
+		}
+	}
+	if exp := "2026-10-12"; s.PerWeek[todo.StatsWeeks-1].Start.Format(todo.DateFormat) != exp {
+		t.Errorf("Expected current week to start on %s, got %s instead.", exp, s.PerWeek[3].Start)
+	}
+
+	if len(s.OldestOpen.Items) != 3 || s.OldestOpen.Items[0].ID != 4 || s.OldestOpen.Items[1].ID != 1 {
+		t.Errorf("Expected items 4 and 1 first, got:\n%s", s.OldestOpen.String())
+	}
+
+	js, err := json.Marshal(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, exp := range []string{`"open":3`, `"medianTimeToCompleteSeconds":171000`,
+		`{"start":"2026-10-14","count":1}`} {
+		if !strings.Contains(string(js), exp) {
+			t.Errorf("Expected %s in %s", exp, js)
+		}
+	}
+}
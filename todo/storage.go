@@ -0,0 +1,167 @@
+package todo
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Storage persists a to-do list. Callers changing the list
+// should hold the storage lock from Get until Save
+type Storage interface {
+	// Get replaces the contents of list with the stored items
+	Get(list *List) error
+	// Save stores the contents of list
+	Save(list *List) error
+	// Lock acquires exclusive access to the storage
+	Lock() (unlock func() error, err error)
+	// Close releases the resources used by the storage
+	Close() error
+}
+
+// Versioner is implemented by the storages other processes can
+// change, such as files the todo CLI also uses, to notice changes
+// without reading the whole list
+type Versioner interface {
+	// Version identifies the stored contents. It changes when
+	// they're saved, at least by another process
+	Version() (string, error)
+}
+
+// Storage kinds accepted by NewStorage
+const (
+	StorageJSON   = "json"
+	StorageSQLite = "sqlite"
+	StorageMemory = "memory"
+)
+
+// NewStorage creates a storage of the given kind using filename.
+// An empty kind means a JSON file, and filename is ignored
+// for in-memory storage. Only JSON files are encrypted
+// with the key set by WithKey
+func NewStorage(kind, filename string, opts ...Option) (Storage, error) {
+	switch strings.ToLower(kind) {
+	case "", StorageJSON:
+		return &jsonStorage{filename: filename, key: newOptions(opts).key}, nil
+	case StorageSQLite, "sqlite3":
+		return newSQLiteStorage(filename)
+	case StorageMemory:
+		return &memoryStorage{}, nil
+	}
+	return nil, fmt.Errorf("unknown storage %q: must be %s, %s or %s",
+		kind, StorageJSON, StorageSQLite, StorageMemory)
+}
+
+// OpenStorage creates a storage from a spec such as "sqlite:todo.db"
+// or "memory:". A spec without a known kind prefix is a JSON file name
+func OpenStorage(spec string, opts ...Option) (Storage, error) {
+	kv := strings.SplitN(spec, ":", 2)
+	if len(kv) == 2 {
+		switch strings.ToLower(kv[0]) {
+		case StorageJSON, StorageSQLite, "sqlite3", StorageMemory:
+			return NewStorage(kv[0], kv[1], opts...)
+		}
+	}
+	return NewStorage(StorageJSON, spec, opts...)
+}
+
+// Update locks the storage, gets the list from it,
+// applies fn and saves the list if fn succeeds
+func Update(s Storage, fn func(list *List) error) error {
+	unlock, err := s.Lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	list := &List{}
+	if err := s.Get(list); err != nil {
+		return err
+	}
+
+	if err := fn(list); err != nil {
+		return err
+	}
+
+	return s.Save(list)
+}
+
+// jsonStorage keeps the whole list in a JSON file,
+// encrypted when key isn't nil
+type jsonStorage struct {
+	filename string
+	key      *Key
+}
+
+func (s *jsonStorage) Get(list *List) error {
+	*list = List{}
+	return list.get(s.filename, s.key)
+}
+
+func (s *jsonStorage) Save(list *List) error {
+	return list.save(s.filename, s.key)
+}
+
+// Version is the modification time and size of the file,
+// empty when the file doesn't exist
+func (s *jsonStorage) Version() (string, error) {
+	fi, err := os.Stat(s.filename)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return "", nil
+		}
+		return "", err
+	}
+	return fmt.Sprintf("%d-%d", fi.ModTime().UnixNano(), fi.Size()), nil
+}
+
+func (s *jsonStorage) Lock() (func() error, error) {
+	return Lock(s.filename)
+}
+
+func (s *jsonStorage) Close() error {
+	return nil
+}
+
+// memoryStorage keeps the list in memory, which is
+// useful for tests and short-lived servers
+type memoryStorage struct {
+	mu    sync.Mutex
+	items List
+}
+
+func (s *memoryStorage) Get(list *List) error {
+	*list = s.items.Clone()
+	return nil
+}
+
+func (s *memoryStorage) Save(list *List) error {
+	s.items = list.Clone()
+	return nil
+}
+
+func (s *memoryStorage) Lock() (func() error, error) {
+	s.mu.Lock()
+	return func() error {
+		s.mu.Unlock()
+		return nil
+	}, nil
+}
+
+func (s *memoryStorage) Close() error {
+	return nil
+}
+
+// Clone returns a copy of the list that shares
+// no memory with it
+func (list List) Clone() List {
+	c := List{Items: make([]item, len(list.Items)), next: list.next}
+	for i, v := range list.Items {
+		v.Tags = append([]string(nil), v.Tags...)
+		v.BlockedBy = append([]int(nil), v.BlockedBy...)
+		c.Items[i] = v
+	}
+	return c
+}
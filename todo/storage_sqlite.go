@@ -0,0 +1,190 @@
+package todo
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// items are stored as JSON documents, one row per item,
+// so new fields don't need schema changes
+const createTableItems = `create table if not exists "items" (
+"id" integer,
+"position" integer not null,
+"data" text not null,
+primary key("id")
+);
+`
+
+// meta keeps the values stored with the list besides
+// its items, such as the ID of the next new item
+const createTableMeta = `create table if not exists "meta" (
+"key" text,
+"value" integer not null,
+primary key("key")
+);
+`
+
+// sqliteStorage keeps one row per item in a SQLite database.
+// Save only writes the items changed since the last Get or Save,
+// so large lists aren't rewritten on every change
+type sqliteStorage struct {
+	filename string
+	db       *sql.DB
+	// rows stored in the database by item ID,
+	// nil until the list is first read or written
+	saved map[int]storedRow
+	// next ID stored in the database
+	savedNext int
+}
+
+type storedRow struct {
+	position int
+	data     string
+}
+
+func newSQLiteStorage(filename string) (*sqliteStorage, error) {
+	db, err := sql.Open("sqlite3", filename)
+	if err != nil {
+		return nil, err
+	}
+
+	db.SetMaxOpenConns(1)
+
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	for _, create := range []string{createTableItems, createTableMeta} {
+		if _, err := db.Exec(create); err != nil {
+			db.Close()
+			return nil, err
+		}
+	}
+
+	return &sqliteStorage{
+		filename: filename,
+		db:       db,
+	}, nil
+}
+
+func (s *sqliteStorage) Get(list *List) error {
+	next := 0
+	err := s.db.QueryRow(`select "value" from "meta" where "key"='nextID'`).Scan(&next)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+
+	rows, err := s.db.Query(`select "id", "position", "data" from "items" order by "position"`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	ls := List{next: next}
+	saved := map[int]storedRow{}
+	for rows.Next() {
+		var (
+			id  int
+			row storedRow
+			i   item
+		)
+		if err := rows.Scan(&id, &row.position, &row.data); err != nil {
+			return err
+		}
+		if err := json.Unmarshal([]byte(row.data), &i); err != nil {
+			return err
+		}
+		i.ID = id
+		ls.Items = append(ls.Items, i)
+		saved[id] = row
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	*list = ls
+	s.saved, s.savedNext = saved, next
+	return nil
+}
+
+func (s *sqliteStorage) Save(list *List) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// without knowing what's stored, replace everything
+	if s.saved == nil {
+		if _, err := tx.Exec(`delete from "items"`); err != nil {
+			return err
+		}
+	}
+
+	saved := map[int]storedRow{}
+	for pos, i := range list.Items {
+		data, err := json.Marshal(i)
+		if err != nil {
+			return err
+		}
+		row := storedRow{position: pos, data: string(data)}
+		saved[i.ID] = row
+
+		if s.saved[i.ID] == row {
+			continue
+		}
+		if _, err := tx.Exec(`insert or replace into "items" values(?,?,?)`,
+			i.ID, row.position, row.data); err != nil {
+			return err
+		}
+	}
+
+	for id := range s.saved {
+		if _, ok := saved[id]; ok {
+			continue
+		}
+		if _, err := tx.Exec(`delete from "items" where "id"=?`, id); err != nil {
+			return err
+		}
+	}
+
+	next := list.NextID()
+	if next != s.savedNext {
+		if _, err := tx.Exec(`insert or replace into "meta" values('nextID',?)`, next); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	s.saved, s.savedNext = saved, next
+	return nil
+}
+
+// Version is the data version of the database, which changes
+// when another connection commits a change. The changes made
+// through this storage don't change it
+func (s *sqliteStorage) Version() (string, error) {
+	var v int64
+	if err := s.db.QueryRow(`pragma data_version`).Scan(&v); err != nil {
+		return "", err
+	}
+	return fmt.Sprint(v), nil
+}
+
+// Lock uses a lock file next to the database as the
+// database itself is only locked during transactions
+func (s *sqliteStorage) Lock() (func() error, error) {
+	return Lock(s.filename)
+}
+
+func (s *sqliteStorage) Close() error {
+	return s.db.Close()
+}
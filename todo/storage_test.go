@@ -0,0 +1,182 @@
+package todo_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"todo"
+)
+
+func TestStorage(t *testing.T) {
+	testCases := []struct {
+		name string
+		spec string
+	}{
+		{name: "JSON", spec: "%s/.todo.json"},
+		{name: "SQLite", spec: "sqlite:%s/todo.db"},
+		{name: "Memory", spec: "memory:"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "todo")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(dir)
+
+			spec := tc.spec
+			if strings.Contains(spec, "%s") {
+				spec = fmt.Sprintf(spec, dir)
+			}
+
+			store, err := todo.OpenStorage(spec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer func() { store.Close() }()
+
+			err = todo.Update(store, func(list *todo.List) error {
+				for _, task := range []string{"Task 1", "Task 2", "Task 3"} {
+					list.Add(task)
+				}
+				return list.AddTags(2, "work")
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = todo.Update(store, func(list *todo.List) error {
+				if err := list.Delete(1); err != nil {
+					return err
+				}
+				return list.Complete(3)
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// read back through a new storage, except for memory
+			// storage which doesn't outlive its value
+			if tc.name != "Memory" {
+				store.Close()
+				if store, err = todo.OpenStorage(spec); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			ls := todo.List{}
+			if err := store.Get(&ls); err != nil {
+				t.Fatal(err)
+			}
+
+			expected := "  2: Task 2 +work\nX 3: Task 3\n"
+			if ls.String() != expected {
+				t.Errorf("Expected %q, got %q instead.", expected, ls.String())
+			}
+
+			// the ID of the last item isn't given again once it's deleted
+			err = todo.Update(store, func(list *todo.List) error {
+				return list.Delete(3)
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tc.name != "Memory" {
+				store.Close()
+				if store, err = todo.OpenStorage(spec); err != nil {
+					t.Fatal(err)
+				}
+			}
+			id := 0
+			err = todo.Update(store, func(list *todo.List) error {
+				id = list.Add("Task 4")
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if id != 4 {
+				t.Errorf("Expected ID 4, got %d instead.", id)
+			}
+		})
+	}
+}
+
+func TestOpenStorage_Unknown(t *testing.T) {
+	if _, err := todo.NewStorage("yaml", "todo.yaml"); err == nil {
+		t.Error("Expected error for unknown storage, got nil")
+	}
+}
+
+func TestStorage_Version(t *testing.T) {
+	testCases := []struct {
+		name string
+		spec string
+	}{
+		{name: "JSON", spec: "%s/.todo.json"},
+		{name: "SQLite", spec: "sqlite:%s/todo.db"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			spec := fmt.Sprintf(tc.spec, t.TempDir())
+
+			store, err := todo.OpenStorage(spec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer store.Close()
+			other, err := todo.OpenStorage(spec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer other.Close()
+
+			version := func() string {
+				t.Helper()
+				v, err := store.(todo.Versioner).Version()
+				if err != nil {
+					t.Fatal(err)
+				}
+				return v
+			}
+
+			ls := todo.List{}
+			if err := store.Get(&ls); err != nil {
+				t.Fatal(err)
+			}
+			ls.Add("Task 1")
+			if err := store.Save(&ls); err != nil {
+				t.Fatal(err)
+			}
+			v := version()
+			if version() != v {
+				t.Errorf("Expected the same version without changes")
+			}
+
+			// changed through another storage, like
+			// another process would
+			err = todo.Update(other, func(list *todo.List) error {
+				list.Add("Task 2")
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if version() == v {
+				t.Errorf("Expected a new version after an external change, got %q", v)
+			}
+		})
+	}
+
+	store, err := todo.OpenStorage("memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := store.(todo.Versioner); ok {
+		t.Errorf("Expected no versions for the memory storage")
+	}
+}
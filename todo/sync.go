@@ -0,0 +1,378 @@
+package todo
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// Remote is the other side of a sync, such as a list on a todoServer
+type Remote interface {
+	// Items returns all the remote items
+	Items() (List, error)
+	// Add adds a task, returning the ID and the
+	// creation time of the new item
+	Add(task string) (int, time.Time, error)
+	Edit(id int, task string) error
+	SetDone(id int, done bool) error
+	Delete(id int) error
+	// MoveTo moves an item to another named list of the remote
+	MoveTo(id int, name string) error
+}
+
+// SyncedItem pairs a local item with a remote one, with the task
+// and status both had after the last sync. Items are known by their
+// ID and creation time, as a list started over, such as the one of
+// a server keeping it in memory once restarted, gives the IDs again.
+// The task is kept as a hash, to keep the state small
+type SyncedItem struct {
+	Local         int       `json:"local"`
+	LocalCreated  time.Time `json:"localCreated"`
+	Remote        int       `json:"remote"`
+	RemoteCreated time.Time `json:"remoteCreated"`
+	TaskHash      string    `json:"taskHash"`
+	Done          bool      `json:"done"`
+}
+
+// SyncState is the state of a named list after its last
+// sync with the remote at URL
+type SyncState struct {
+	URL   string       `json:"url"`
+	List  string       `json:"list"`
+	Items []SyncedItem `json:"items"`
+}
+
+// SyncStates are the states of the lists synced from a file
+type SyncStates []SyncState
+
+// Find returns the state of the named list synced with the remote
+// at url, adding an empty one if they were never synced
+func (s *SyncStates) Find(url, name string) *SyncState {
+	name = normalizeListName(name)
+	for i := range *s {
+		if (*s)[i].URL == url && (*s)[i].List == name {
+			return &(*s)[i]
+		}
+	}
+	*s = append(*s, SyncState{URL: url, List: name})
+	return &(*s)[len(*s)-1]
+}
+
+// Load reads the states from a JSON file, decrypting it with the key
+// set by WithKey when it's encrypted. A missing file has no states
+func (s *SyncStates) Load(filename string, opts ...Option) error {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	if len(data) == 0 {
+		return nil
+	}
+	if IsEncrypted(data) {
+		if data, err = newOptions(opts).key.decrypt(data); err != nil {
+			return fmt.Errorf("%s: %w", filename, err)
+		}
+	}
+	return json.Unmarshal(data, s)
+}
+
+// Save writes the states to a JSON file, encrypted like the list with
+// the key set by WithKey, as the task hashes could be matched against
+// guessed tasks
+func (s *SyncStates) Save(filename string, opts ...Option) error {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	if key := newOptions(opts).key; key != nil {
+		if data, err = key.encrypt(data); err != nil {
+			return err
+		}
+	}
+	return writeFileAtomic(filename, data, 0644)
+}
+
+// SyncConflict is a change made on both sides, and
+// how the sync resolved it
+type SyncConflict struct {
+	ID     int
+	Task   string
+	Reason string
+}
+
+// String describes the conflict in one line
+func (c SyncConflict) String() string {
+	if c.ID == 0 {
+		return fmt.Sprintf("%s: %s", c.Task, c.Reason)
+	}
+	return fmt.Sprintf("%d: %s: %s", c.ID, c.Task, c.Reason)
+}
+
+// SyncReport counts the changes made by a sync on each side,
+// with the conflicts found
+type SyncReport struct {
+	Sent      int
+	Received  int
+	Conflicts []SyncConflict
+}
+
+// Sync reconciles the named list with a remote, from the state of
+// both after the last sync, which is updated. Items added, edited,
+// completed, reopened or deleted on either side since then are
+// changed on the other one. Items moved here to another list are
+// moved to that list on the remote, not deleted. Conflicts are resolved without losing
+// changes: a task edited on both sides takes the remote task and
+// an item deleted on one side but changed on the other is kept.
+// Items with the same task on both sides that weren't synced
+// before, such as on the first sync, are paired instead of copied
+func (list *List) Sync(r Remote, name string, state *SyncState) (SyncReport, error) {
+	var report SyncReport
+
+	remote, err := r.Items()
+	if err != nil {
+		return report, err
+	}
+	local := list.Named(name)
+
+	var synced []SyncedItem
+	pairedLocal := map[int]bool{}
+	pairedRemote := map[int]bool{}
+	conflict := func(id int, task, reason string) {
+		report.Conflicts = append(report.Conflicts, SyncConflict{ID: id, Task: task, Reason: reason})
+	}
+
+	for _, base := range state.Items {
+		li, lerr := local.indexOfCreated(base.Local, base.LocalCreated)
+		ri, rerr := remote.indexOfCreated(base.Remote, base.RemoteCreated)
+
+		switch {
+		case lerr != nil && rerr != nil:
+			continue
+		case lerr != nil:
+			rv := remote.Items[ri]
+			if to, ok := list.movedFrom(base); ok {
+				if err := r.MoveTo(rv.ID, to); err != nil {
+					return report, err
+				}
+				pairedRemote[rv.ID] = true
+				report.Sent++
+				continue
+			}
+
+			// deleted here, unless changed on the remote
+			// meanwhile, then it's added back as a new item
+			if changedSince(rv, base) {
+				conflict(0, rv.Task, "deleted here but changed on the server, added back")
+				continue
+			}
+			if err := r.Delete(rv.ID); err != nil {
+				return report, err
+			}
+			pairedRemote[rv.ID] = true
+			report.Sent++
+			continue
+		case rerr != nil:
+			lv := local.Items[li]
+			if changedSince(lv, base) {
+				conflict(lv.ID, lv.Task, "deleted on the server but changed here, added back")
+				continue
+			}
+			if err := list.Delete(lv.ID); err != nil {
+				return report, err
+			}
+			pairedLocal[lv.ID] = true
+			report.Received++
+			continue
+		}
+
+		lv, rv := local.Items[li], remote.Items[ri]
+		pairedLocal[lv.ID] = true
+		pairedRemote[rv.ID] = true
+		s := newSyncedItem(lv, rv)
+		s.Done = lv.Done
+
+		localEdit, remoteEdit := taskHash(lv.Task) != base.TaskHash, taskHash(rv.Task) != base.TaskHash
+		switch {
+		case localEdit && remoteEdit && lv.Task != rv.Task:
+			conflict(lv.ID, lv.Task, fmt.Sprintf("edited on both sides, replaced by %q from the server", rv.Task))
+			fallthrough
+		case remoteEdit && !localEdit:
+			if err := list.Edit(lv.ID, rv.Task); err != nil {
+				return report, err
+			}
+			s.TaskHash = taskHash(rv.Task)
+			report.Received++
+		case localEdit && !remoteEdit:
+			if err := r.Edit(rv.ID, lv.Task); err != nil {
+				return report, err
+			}
+			report.Sent++
+		}
+
+		// the status can only change the same way on both sides.
+		// Blocked items keep the last synced status to try again
+		var err error
+		switch {
+		case lv.Done != base.Done && rv.Done == base.Done:
+			err = r.SetDone(rv.ID, lv.Done)
+			if err == nil {
+				report.Sent++
+			}
+		case rv.Done != base.Done && lv.Done == base.Done:
+			err = list.setDone(lv.ID, rv.Done)
+			if err == nil {
+				s.Done = rv.Done
+				report.Received++
+			}
+		}
+		if err != nil {
+			if !errors.Is(err, ErrBlocked) {
+				return report, err
+			}
+			conflict(lv.ID, lv.Task, err.Error())
+			s.Done = base.Done
+		}
+		synced = append(synced, s)
+	}
+
+	// pair the new items with the same task on both sides,
+	// a completed one completing the other
+	var newRemote []item
+	for _, rv := range remote.Items {
+		if !pairedRemote[rv.ID] {
+			newRemote = append(newRemote, rv)
+		}
+	}
+	for _, lv := range local.Items {
+		if pairedLocal[lv.ID] {
+			continue
+		}
+
+		ri := -1
+		for k, rv := range newRemote {
+			if rv.Task == lv.Task {
+				ri = k
+				break
+			}
+		}
+
+		var s SyncedItem
+		if ri < 0 {
+			id, created, err := r.Add(lv.Task)
+			if err != nil {
+				return report, err
+			}
+			report.Sent++
+			s = SyncedItem{Local: lv.ID, LocalCreated: lv.CreateAt,
+				Remote: id, RemoteCreated: created, TaskHash: taskHash(lv.Task)}
+		} else {
+			s = newSyncedItem(lv, newRemote[ri])
+			newRemote = append(newRemote[:ri], newRemote[ri+1:]...)
+		}
+
+		var err error
+		switch {
+		case lv.Done && !s.Done:
+			if err = r.SetDone(s.Remote, true); err == nil {
+				s.Done = true
+				report.Sent++
+			}
+		case !lv.Done && s.Done:
+			if err = list.setDone(lv.ID, true); err == nil {
+				report.Received++
+			} else {
+				s.Done = false
+			}
+		}
+		if err != nil {
+			if !errors.Is(err, ErrBlocked) {
+				return report, err
+			}
+			conflict(lv.ID, lv.Task, err.Error())
+		}
+		synced = append(synced, s)
+	}
+
+	// the remaining remote items are new here
+	for _, rv := range newRemote {
+		id := list.Add(rv.Task)
+		if err := list.MoveTo(id, name); err != nil {
+			return report, err
+		}
+		if rv.Done {
+			if err := list.Complete(id); err != nil {
+				return report, err
+			}
+		}
+		report.Received++
+
+		i, err := list.IndexOf(id)
+		if err != nil {
+			return report, err
+		}
+		synced = append(synced, newSyncedItem(list.Items[i], rv))
+	}
+
+	state.Items = synced
+	return report, nil
+}
+
+// newSyncedItem pairs a local item with a remote one,
+// with the task of the local one and the remote status
+func newSyncedItem(lv, rv item) SyncedItem {
+	return SyncedItem{
+		Local:         lv.ID,
+		LocalCreated:  lv.CreateAt,
+		Remote:        rv.ID,
+		RemoteCreated: rv.CreateAt,
+		TaskHash:      taskHash(lv.Task),
+		Done:          rv.Done,
+	}
+}
+
+// indexOfCreated returns the index of the item with the given ID
+// created at the given time, not another one given the same ID
+func (list *List) indexOfCreated(id int, created time.Time) (int, error) {
+	i, err := list.IndexOf(id)
+	if err != nil {
+		return -1, err
+	}
+	if !list.Items[i].CreateAt.Equal(created) {
+		return -1, fmt.Errorf("item %d does not exist", id)
+	}
+	return i, nil
+}
+
+// movedFrom returns the list of the synced item when it's still in
+// the list, which is another one than the synced list it's missing from
+func (list *List) movedFrom(base SyncedItem) (string, bool) {
+	i, err := list.indexOfCreated(base.Local, base.LocalCreated)
+	if err != nil {
+		return "", false
+	}
+	return list.Items[i].listName(), true
+}
+
+// changedSince reports whether the task or status of
+// the item changed since the synced state
+func changedSince(v item, base SyncedItem) bool {
+	return taskHash(v.Task) != base.TaskHash || v.Done != base.Done
+}
+
+// setDone completes or reopens the item with the given ID
+func (list *List) setDone(id int, done bool) error {
+	if done {
+		return list.Complete(id)
+	}
+	return list.Reopen(id)
+}
+
+func taskHash(task string) string {
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(task)))
+}
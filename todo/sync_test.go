@@ -0,0 +1,283 @@
+package todo_test
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+	"todo"
+)
+
+// memoryRemote is a remote list kept in memory, in its default list
+type memoryRemote struct {
+	list todo.List
+	// the IDs of the items that can't be completed
+	blocked map[int]bool
+}
+
+func (m *memoryRemote) Items() (todo.List, error) {
+	return m.list.Named(todo.DefaultList), nil
+}
+
+func (m *memoryRemote) Add(task string) (int, time.Time, error) {
+	id := m.list.Add(task)
+	return id, m.list.Items[len(m.list.Items)-1].CreateAt, nil
+}
+
+func (m *memoryRemote) Edit(id int, task string) error {
+	return m.list.Edit(id, task)
+}
+
+func (m *memoryRemote) SetDone(id int, done bool) error {
+	if m.blocked[id] {
+		return fmt.Errorf("%w: item %d", todo.ErrBlocked, id)
+	}
+	if done {
+		return m.list.Complete(id)
+	}
+	return m.list.Reopen(id)
+}
+
+func (m *memoryRemote) Delete(id int) error {
+	return m.list.Delete(id)
+}
+
+func (m *memoryRemote) MoveTo(id int, name string) error {
+	return m.list.MoveTo(id, name)
+}
+
+func TestList_Sync(t *testing.T) {
+	ls := todo.List{}
+	ls.Add("buy milk")
+	ls.Add("call mom")
+	ls.Complete(2)
+
+	r := &memoryRemote{}
+	r.list.Add("call mom")
+	r.list.Add("write report")
+
+	state := &todo.SyncState{}
+
+	t.Run("First", func(t *testing.T) {
+		report, err := ls.Sync(r, todo.DefaultList, state)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if report.Sent != 2 || report.Received != 1 || len(report.Conflicts) != 0 {
+			t.Errorf("Unexpected report %+v", report)
+		}
+
+		expLocal := "  1: buy milk\nX 2: call mom\n  3: write report\n"
+		expRemote := "X 1: call mom\n  2: write report\n  3: buy milk\n"
+		if ls.String() != expLocal {
+			t.Errorf("Expected local:\n%s\ngot:\n%s", expLocal, ls.String())
+		}
+		if r.list.String() != expRemote {
+			t.Errorf("Expected remote:\n%s\ngot:\n%s", expRemote, r.list.String())
+		}
+		if len(state.Items) != 3 {
+			t.Errorf("Expected 3 synced items, got %+v", state.Items)
+		}
+	})
+
+	t.Run("Changes", func(t *testing.T) {
+		// edited and completed here, reopened and
+		// deleted on the remote, added on both
+		ls.Edit(1, "buy oat milk")
+		ls.Complete(3)
+		ls.Add("pay rent")
+		r.list.Reopen(1)
+		r.list.Delete(3)
+		r.list.Add("book flights")
+
+		report, err := ls.Sync(r, todo.DefaultList, state)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// the deleted item was changed here, so it's kept
+		if report.Sent != 3 || report.Received != 2 || len(report.Conflicts) != 1 {
+			t.Errorf("Unexpected report %+v", report)
+		}
+		if !strings.Contains(report.Conflicts[0].String(), "1: buy oat milk: deleted on the server") {
+			t.Errorf("Unexpected conflict %q", report.Conflicts[0])
+		}
+
+		expLocal := "  1: buy oat milk\n  2: call mom\nX 3: write report\n  4: pay rent\n  5: book flights\n"
+		expRemote := "  1: call mom\nX 2: write report\n  4: book flights\n  5: buy oat milk\n  6: pay rent\n"
+		if ls.String() != expLocal {
+			t.Errorf("Expected local:\n%s\ngot:\n%s", expLocal, ls.String())
+		}
+		if r.list.String() != expRemote {
+			t.Errorf("Expected remote:\n%s\ngot:\n%s", expRemote, r.list.String())
+		}
+	})
+
+	t.Run("Unchanged", func(t *testing.T) {
+		report, err := ls.Sync(r, todo.DefaultList, state)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if report.Sent != 0 || report.Received != 0 || len(report.Conflicts) != 0 {
+			t.Errorf("Expected no changes, got %+v", report)
+		}
+	})
+
+	t.Run("Conflicts", func(t *testing.T) {
+		// edited on both sides, deleted here but edited on
+		// the remote, and blocked on the remote
+		ls.Edit(2, "call dad")
+		r.list.Edit(1, "call mom and dad")
+		ls.Delete(4)
+		r.list.Edit(6, "pay the rent")
+		ls.Complete(5)
+		r.blocked = map[int]bool{4: true}
+
+		report, err := ls.Sync(r, todo.DefaultList, state)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(report.Conflicts) != 3 {
+			t.Fatalf("Expected 3 conflicts, got %v", report.Conflicts)
+		}
+		if ls.String() != "  1: buy oat milk\n  2: call mom and dad\nX 3: write report\nX 5: book flights\n  6: pay the rent\n" {
+			t.Errorf("Unexpected local list:\n%s", ls.String())
+		}
+
+		// the blocked item is completed once it's unblocked
+		r.blocked = nil
+		report, err = ls.Sync(r, todo.DefaultList, state)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if report.Sent != 1 || !r.list.Items[2].Done {
+			t.Errorf("Expected the remote item completed, got %+v:\n%s", report, r.list.String())
+		}
+	})
+}
+
+func TestList_SyncNamed(t *testing.T) {
+	ls := todo.List{}
+	ls.Add("buy milk")
+	id := ls.Add("write report")
+	ls.MoveTo(id, "work")
+
+	r := &memoryRemote{}
+	state := &todo.SyncState{}
+	if _, err := ls.Sync(r, "work", state); err != nil {
+		t.Fatal(err)
+	}
+	if r.list.String() != "  1: write report\n" {
+		t.Errorf("Expected only the work list, got:\n%s", r.list.String())
+	}
+
+	r.list.Add("prepare slides")
+	if _, err := ls.Sync(r, "work", state); err != nil {
+		t.Fatal(err)
+	}
+	work := ls.Named("work")
+	if len(work.Items) != 2 || work.Items[1].Task != "prepare slides" {
+		t.Errorf("Expected the new item in the work list, got:\n%s", work.String())
+	}
+}
+
+func TestList_SyncMoved(t *testing.T) {
+	ls := todo.List{}
+	ls.Add("buy milk")
+	id := ls.Add("write report")
+
+	r := &memoryRemote{}
+	state := &todo.SyncState{}
+	if _, err := ls.Sync(r, todo.DefaultList, state); err != nil {
+		t.Fatal(err)
+	}
+
+	// moved here, so moved on the remote instead of deleted
+	if err := ls.MoveTo(id, "work"); err != nil {
+		t.Fatal(err)
+	}
+	report, err := ls.Sync(r, todo.DefaultList, state)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Sent != 1 || len(report.Conflicts) != 0 {
+		t.Errorf("Unexpected report %+v", report)
+	}
+	if work := r.list.Named("work"); work.String() != "  2: write report\n" {
+		t.Errorf("Expected the item moved on the remote, got:\n%s", r.list.String())
+	}
+	if len(state.Items) != 1 {
+		t.Errorf("Expected 1 synced item left, got %+v", state.Items)
+	}
+}
+
+func TestSyncStates(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), ".todo.json.sync")
+
+	states := todo.SyncStates{}
+	if err := states.Load(filename); err != nil {
+		t.Fatal(err)
+	}
+	s := states.Find("http://localhost:8080", "")
+	s.Items = append(s.Items, todo.SyncedItem{Local: 1, Remote: 3})
+	states.Find("http://localhost:8080", "work")
+	if err := states.Save(filename); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "buy milk") {
+		t.Errorf("Expected no tasks in the state")
+	}
+
+	got := todo.SyncStates{}
+	if err := got.Load(filename); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("Expected 2 states, got %+v", got)
+	}
+	s = got.Find("http://localhost:8080", todo.DefaultList)
+	if len(s.Items) != 1 || s.Items[0].Remote != 3 {
+		t.Errorf("Unexpected state %+v", s)
+	}
+}
+
+func TestSyncStates_Encrypted(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), ".todo.json.sync")
+
+	key := todo.WithKey(todo.NewKey("correct horse"))
+	states := todo.SyncStates{}
+	s := states.Find("http://localhost:8080", "")
+	s.Items = append(s.Items, todo.SyncedItem{Local: 1, Remote: 3, TaskHash: "hash"})
+	if err := states.Save(filename, key); err != nil {
+		t.Fatal(err)
+	}
+
+	// the task hashes could be matched against guessed tasks
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !todo.IsEncrypted(data) || strings.Contains(string(data), "hash") {
+		t.Errorf("Expected the state encrypted, got %q", data)
+	}
+
+	got := todo.SyncStates{}
+	if err := got.Load(filename, key); err != nil {
+		t.Fatal(err)
+	}
+	if s := got.Find("http://localhost:8080", ""); len(s.Items) != 1 || s.Items[0].TaskHash != "hash" {
+		t.Errorf("Unexpected state %+v", s)
+	}
+
+	if err := got.Load(filename); !errors.Is(err, todo.ErrPassphraseRequired) {
+		t.Errorf("Expected ErrPassphraseRequired, got %v", err)
+	}
+}
@@ -1,73 +1,342 @@
 package todo
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
+	"sort"
+	"strings"
 	"time"
 )
 
+// DateFormat is the layout used to read and display due dates,
+// and DueTimeFormat the one for due dates with a time
+const (
+	DateFormat    = "2006-01-02"
+	DueTimeFormat = "2006-01-02T15:04"
+)
+
+// ErrNotDone is returned when reopening an item that isn't done
+var ErrNotDone = errors.New("item is not done")
+
 type item struct {
+	ID          int
 	Task        string
 	Done        bool
 	CreateAt    time.Time
 	CompletedAt time.Time
+	Priority    string
+	Due         time.Time
+	Tags        []string
+	Notes       string
+	Recur       string
+	// Parent is the ID of the item this one is a subtask of, 0 if none
+	Parent int
+	// BlockedBy are the IDs of the items to complete before this one
+	BlockedBy []int
+	// ListName is the name of the list the item is in,
+	// empty for the default list
+	ListName string
 }
 
-type List []item
+// List is a to-do list. Besides its items, it keeps the ID the next
+// new item gets, so the IDs of deleted or archived items are never
+// given to other items
+type List struct {
+	Items []item
+	// next is the lowest ID a new item can get
+	next int
+}
 
-// Add creates a new to-do item and appends it to the list
-func (list *List) Add(task string) {
+// Add creates a new to-do item and appends it to the list.
+// The item gets an ID that stays the same for as long as
+// it's in the list, and isn't reused once the item is gone.
+// Add returns the ID of the new item
+func (list *List) Add(task string) int {
 	t := item{
+		ID:          list.nextID(),
 		Task:        task,
 		Done:        false,
 		CreateAt:    time.Now(),
 		CompletedAt: time.Time{},
 	}
-	*list = append(*list, t)
+	list.Items = append(list.Items, t)
+
+	return t.ID
+}
+
+// SetPriority sets the priority of the to-do item with the given ID.
+// Priorities are single letters from A (highest) to Z,
+// an empty string clears the priority
+func (list *List) SetPriority(id int, priority string) error {
+	i, err := list.IndexOf(id)
+	if err != nil {
+		return err
+	}
+
+	if priority, err = parsePriority(priority); err != nil {
+		return err
+	}
+	list.Items[i].Priority = priority
+
+	return nil
+}
+
+// parsePriority checks priority is a letter from A to Z, or
+// empty, and returns it in upper case
+func parsePriority(priority string) (string, error) {
+	priority = strings.ToUpper(priority)
+	if priority != "" && (len(priority) != 1 || priority < "A" || priority > "Z") {
+		return "", fmt.Errorf("invalid priority %q: must be a letter from A to Z", priority)
+	}
+	return priority, nil
+}
+
+// SetDue sets the due date of the to-do item with the given ID.
+// Due dates at midnight have no due time. A zero time clears the due date
+func (list *List) SetDue(id int, due time.Time) error {
+	i, err := list.IndexOf(id)
+	if err != nil {
+		return err
+	}
+
+	list.Items[i].Due = due
+
+	return nil
+}
+
+// AddTags adds the given tags to the to-do item with the given ID.
+// Tags are kept sorted and each tag appears only once
+func (list *List) AddTags(id int, tags ...string) error {
+	i, err := list.IndexOf(id)
+	if err != nil {
+		return err
+	}
+
+	ls := list.Items
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag == "" || ls[i].HasTag(tag) {
+			continue
+		}
+		ls[i].Tags = append(ls[i].Tags, tag)
+	}
+	sort.Strings(ls[i].Tags)
+
+	return nil
+}
+
+// SetNotes sets the notes of the to-do item with the given ID,
+// a longer free-form text attached to the task
+func (list *List) SetNotes(id int, notes string) error {
+	i, err := list.IndexOf(id)
+	if err != nil {
+		return err
+	}
+
+	list.Items[i].Notes = strings.TrimSpace(notes)
+
+	return nil
+}
+
+// HasTag reports whether the item is tagged with the given tag
+func (i item) HasTag(tag string) bool {
+	for _, t := range i.Tags {
+		if strings.EqualFold(t, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// Complete marks the to-do item with the given ID as completed by
+// setting Done=true and CompletedAt to the current time.
+// Completing a recurring item also adds its next occurrence
+// at the end of the list. Items with open blockers can't be completed
+func (list *List) Complete(id int) error {
+	i, err := list.IndexOf(id)
+	if err != nil {
+		return err
+	}
+
+	open, err := list.OpenBlockers(id)
+	if err != nil {
+		return err
+	}
+	if len(open) > 0 {
+		return fmt.Errorf("%w: item %d is blocked by %v", ErrBlocked, id, open)
+	}
+
+	ls := list.Items
+	wasDone := ls[i].Done
+	ls[i].Done = true
+	ls[i].CompletedAt = time.Now()
+
+	if ls[i].Recur != "" && !wasDone {
+		return list.scheduleNext(i)
+	}
+	return nil
 }
 
-// Complete marks a to-do item as completed by
-// setting Done=true and CompletedAt to the current time
-func (list *List) Complete(i int) error {
-	ls := *list
-	if i <= 0 || i > len(ls) {
-		return fmt.Errorf("item %d does not exist", i)
+// Delete remove the to-do item with the given ID from the list.
+// Its subtasks move up to its parent and the items it
+// blocked aren't blocked by it anymore
+func (list *List) Delete(id int) error {
+	i, err := list.IndexOf(id)
+	if err != nil {
+		return err
 	}
 
-	ls[i-1].Done = true
-	ls[i-1].CompletedAt = time.Now()
+	list.unlink(id)
+	ls := list.Items
+	list.Items = append(ls[:i], ls[i+1:]...)
 
 	return nil
 }
 
-// Delete remove a to-do item from the list
-func (list *List) Delete(i int) error {
-	ls := *list
-	if i <= 0 || i > len(ls) {
-		return fmt.Errorf("item %d does not exist", i)
+// Edit replaces the task of the to-do item with the given ID
+func (list *List) Edit(id int, task string) error {
+	i, err := list.IndexOf(id)
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(task) == "" {
+		return fmt.Errorf("task cannot be blank")
 	}
 
-	*list = append(ls[:i-1], ls[i:]...)
+	list.Items[i].Task = task
 
 	return nil
 }
 
-// Save encodes the List as JSON and saves it using
-// the provided file name
+// Reopen marks a completed to-do item as not done,
+// clearing its completion time. Open items can't be reopened
+func (list *List) Reopen(id int) error {
+	i, err := list.IndexOf(id)
+	if err != nil {
+		return err
+	}
+
+	ls := list.Items
+	if !ls[i].Done {
+		return fmt.Errorf("%w: item %d", ErrNotDone, id)
+	}
+	ls[i].Done = false
+	ls[i].CompletedAt = time.Time{}
+
+	return nil
+}
+
+// Move moves the to-do item with the given ID to the given
+// 1-based position in its named list, shifting the items in between
+func (list *List) Move(id int, pos int) error {
+	i, err := list.IndexOf(id)
+	if err != nil {
+		return err
+	}
+
+	// indexes of the items in the same named list
+	ls := list.Items
+	var named []int
+	for k, v := range ls {
+		if v.ListName == ls[i].ListName {
+			named = append(named, k)
+		}
+	}
+	if pos <= 0 || pos > len(named) {
+		return fmt.Errorf("position %d out of range 1-%d", pos, len(named))
+	}
+
+	v := ls[i]
+	j := named[pos-1]
+	if j < i {
+		copy(ls[j+1:i+1], ls[j:i])
+	} else {
+		copy(ls[i:j], ls[i+1:j+1])
+	}
+	ls[j] = v
+
+	return nil
+}
+
+// IndexOf returns the position in the list of the
+// to-do item with the given ID
+func (list *List) IndexOf(id int) (int, error) {
+	for i, v := range list.Items {
+		if v.ID == id {
+			return i, nil
+		}
+	}
+	return -1, fmt.Errorf("item %d does not exist", id)
+}
+
+// nextID returns a new ID, greater than any ID given before,
+// and reserves it
+func (list *List) nextID() int {
+	id := list.NextID()
+	list.next = id + 1
+	return id
+}
+
+// NextID returns the ID the next new item gets, which is greater
+// than the ID of any item in the list or removed from it
+func (list *List) NextID() int {
+	id := list.next
+	if id < 1 {
+		id = 1
+	}
+	for _, v := range list.Items {
+		if v.ID >= id {
+			id = v.ID + 1
+		}
+	}
+	return id
+}
+
+// assignIDs gives an ID to the items without one, which is
+// the case for lists saved before items had IDs
+func (list *List) assignIDs() {
+	ls := list.Items
+	for i := range ls {
+		if ls[i].ID == 0 {
+			ls[i].ID = list.nextID()
+		}
+	}
+}
+
+// Save encodes the List as JSON, with the ID of the next new item,
+// and saves it using the provided file name. The file is replaced atomically,
+// so it's never left partially written
 func (list *List) Save(filename string) error {
-	js, err := json.Marshal(list)
+	return list.save(filename, nil)
+}
+
+// save saves the list like Save, encrypted with key unless it's nil
+func (list *List) save(filename string, key *Key) error {
+	js, err := json.Marshal(savedList{NextID: list.NextID(), Items: list.Items})
 	if err != nil {
 		return err
 	}
+	if key != nil {
+		if js, err = key.encrypt(js); err != nil {
+			return err
+		}
+	}
 
-	return os.WriteFile(filename, js, 0644)
+	return writeFileAtomic(filename, js, 0644)
 }
 
 // Get open the proided file name, decodes
 // the JSON data and parses it into a list
 func (list *List) Get(filename string) error {
+	return list.get(filename, nil)
+}
+
+// get reads the list like Get, decrypting it with key
+// when it's encrypted
+func (list *List) get(filename string, key *Key) error {
 	file, err := os.ReadFile(filename)
 	if err != nil {
 		if errors.Is(err, os.ErrNotExist) {
@@ -78,19 +347,107 @@ func (list *List) Get(filename string) error {
 	if len(file) == 0 {
 		return nil
 	}
-	return json.Unmarshal(file, list)
+	if IsEncrypted(file) {
+		if file, err = key.decrypt(file); err != nil {
+			return fmt.Errorf("%s: %w", filename, err)
+		}
+	}
+	if err := json.Unmarshal(file, list); err != nil {
+		return err
+	}
+
+	list.assignIDs()
+	return nil
+}
+
+// savedList is a list as saved in a file, with the ID of the
+// next new item. Files saved before only have the items
+type savedList struct {
+	NextID int
+	Items  []item
+}
+
+// MarshalJSON encodes the items of the list as a JSON array
+func (list List) MarshalJSON() ([]byte, error) {
+	if list.Items == nil {
+		return []byte("[]"), nil
+	}
+	return json.Marshal(list.Items)
 }
 
+// UnmarshalJSON decodes either a JSON array of items or
+// a list as saved in a file
+func (list *List) UnmarshalJSON(data []byte) error {
+	if js := bytes.TrimSpace(data); len(js) > 0 && js[0] == '[' {
+		var items []item
+		if err := json.Unmarshal(js, &items); err != nil {
+			return err
+		}
+		*list = List{Items: items}
+		return nil
+	}
+
+	var saved savedList
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return err
+	}
+	*list = List{Items: saved.Items, next: saved.NextID}
+	return nil
+}
+
+// String formats the list one item per line, with
+// subtasks indented under their parent
 func (list *List) String() string {
 	formatted := ""
 
-	for i, v := range *list {
+	items, depths := list.tree()
+	for k, v := range items {
 		prefix := "  "
 		if v.Done {
 			prefix = "X "
 		}
-		formatted += fmt.Sprintf("%s%d: %s\n", prefix, i+1, v.Task)
+		formatted += fmt.Sprintf("%s%s%d: %s%s%s\n", prefix, strings.Repeat("  ", depths[k]),
+			v.ID, v.Task, v.details(), v.blockedWord())
 	}
 
 	return formatted
 }
+
+// details formats the optional priority, due date, recurrence
+// and tags of an item, in the order they're shown
+func (i item) details() string {
+	var b strings.Builder
+	if i.Priority != "" {
+		fmt.Fprintf(&b, " (%s)", i.Priority)
+	}
+	if !i.Due.IsZero() {
+		fmt.Fprintf(&b, " due:%s", FormatDue(i.Due))
+	}
+	if i.Recur != "" {
+		fmt.Fprintf(&b, " rec:%s", i.Recur)
+	}
+	for _, t := range tagWords(i.Tags) {
+		fmt.Fprintf(&b, " %s", t)
+	}
+	return b.String()
+}
+
+// ParseDue parses a due date in the local time zone, with an
+// optional time as in DueTimeFormat or separated by a space
+func ParseDue(s string) (time.Time, error) {
+	for _, layout := range []string{DueTimeFormat, "2006-01-02 15:04"} {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.ParseInLocation(DateFormat, s, time.Local)
+}
+
+// FormatDue formats a due date as parsed by ParseDue,
+// with the time only when it isn't midnight
+func FormatDue(t time.Time) string {
+	if t.Hour() == 0 && t.Minute() == 0 {
+		return t.Format(DateFormat)
+	}
+	return t.Format(DueTimeFormat)
+}
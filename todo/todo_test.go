@@ -1,9 +1,12 @@
 package todo_test
 
 import (
+	"errors"
+	"fmt"
 	"io/ioutil"
 	"os"
 	"testing"
+	"time"
 	"todo"
 )
 
@@ -13,8 +16,8 @@ func TestList_Add(t *testing.T) {
 	task := "New Task"
 	ls.Add(task)
 
-	if ls[0].Task != task {
-		t.Errorf("Expected %q, got %q instead.", task, ls[0].Task)
+	if ls.Items[0].Task != task {
+		t.Errorf("Expected %q, got %q instead.", task, ls.Items[0].Task)
 	}
 }
 
@@ -23,16 +26,16 @@ func TestList_Complete(t *testing.T) {
 
 	task := "New Task"
 	ls.Add(task)
-	if ls[0].Task != task {
-		t.Errorf("Expected %q, got %q instead.", task, ls[0].Task)
+	if ls.Items[0].Task != task {
+		t.Errorf("Expected %q, got %q instead.", task, ls.Items[0].Task)
 	}
 
-	if ls[0].Done {
+	if ls.Items[0].Done {
 		t.Error("New task should not be completed")
 	}
 
 	ls.Complete(1)
-	if !ls[0].Done {
+	if !ls.Items[0].Done {
 		t.Error("New task should be completed")
 	}
 }
@@ -49,16 +52,16 @@ func TestList_Delete(t *testing.T) {
 		ls.Add(v)
 	}
 
-	if ls[0].Task != tasks[0] {
-		t.Errorf("Expected %q, got %q instead.", tasks[0], ls[0].Task)
+	if ls.Items[0].Task != tasks[0] {
+		t.Errorf("Expected %q, got %q instead.", tasks[0], ls.Items[0].Task)
 	}
 
 	ls.Delete(2)
-	if len(ls) != 2 {
-		t.Errorf("Expected list length 2, got %d instead.", len(ls))
+	if len(ls.Items) != 2 {
+		t.Errorf("Expected list length 2, got %d instead.", len(ls.Items))
 	}
-	if ls[1].Task != tasks[2] {
-		t.Errorf("Expected %q, got %q instead.", tasks[2], ls[1].Task)
+	if ls.Items[1].Task != tasks[2] {
+		t.Errorf("Expected %q, got %q instead.", tasks[2], ls.Items[1].Task)
 	}
 }
 
@@ -68,8 +71,8 @@ func TestList_SaveGet(t *testing.T) {
 
 	task := "New Task"
 	ls1.Add(task)
-	if ls1[0].Task != task {
-		t.Errorf("Expected %q, got %q instead.", task, ls1[0].Task)
+	if ls1.Items[0].Task != task {
+		t.Errorf("Expected %q, got %q instead.", task, ls1.Items[0].Task)
 	}
 
 	tf, err := ioutil.TempFile("", "")
@@ -84,10 +87,219 @@ func TestList_SaveGet(t *testing.T) {
 	if err := ls2.Get(tf.Name()); err != nil {
 		t.Fatalf("Error getting list from file: %s", err)
 	}
-	if len(ls2) != len(ls1) {
-		t.Errorf("Expected list length %q, got %d instead.", len(ls1), len(ls2))
+	if len(ls2.Items) != len(ls1.Items) {
+		t.Errorf("Expected list length %q, got %d instead.", len(ls1.Items), len(ls2.Items))
 	}
-	if ls1[0].Task != ls2[0].Task {
-		t.Errorf("Task %q should match %q task.", ls1[0].Task, ls2[0].Task)
+	if ls1.Items[0].Task != ls2.Items[0].Task {
+		t.Errorf("Task %q should match %q task.", ls1.Items[0].Task, ls2.Items[0].Task)
+	}
+}
+
+func TestList_StableIDs(t *testing.T) {
+	ls := todo.List{}
+
+	tasks := []string{
+		"New Task 1",
+		"New Task 2",
+		"New Task 3",
+	}
+	for _, v := range tasks {
+		ls.Add(v)
+	}
+
+	if err := ls.Delete(2); err != nil {
+		t.Fatal(err)
+	}
+	if err := ls.Complete(3); err != nil {
+		t.Fatal(err)
+	}
+	if !ls.Items[1].Done {
+		t.Errorf("Expected %q to be completed", ls.Items[1].Task)
+	}
+	if err := ls.Complete(2); err == nil {
+		t.Error("Expected error completing deleted item, got nil")
+	}
+
+	ls.Add("New Task 4")
+	if ls.Items[2].ID != 4 {
+		t.Errorf("Expected ID 4, got %d instead.", ls.Items[2].ID)
+	}
+}
+
+func TestList_DeleteLastThenAdd(t *testing.T) {
+	ls := todo.List{}
+	ls.Add("New Task 1")
+	ls.Add("New Task 2")
+
+	if err := ls.Delete(2); err != nil {
+		t.Fatal(err)
+	}
+	if id := ls.Add("New Task 3"); id != 3 {
+		t.Errorf("Expected ID 3, got %d instead.", id)
+	}
+
+	// the next ID is saved with the list
+	for _, id := range []int{3, 1} {
+		if err := ls.Delete(id); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tf, err := ioutil.TempFile("", "")
+	if err != nil {
+		t.Fatalf("Error creating temp file: %s", err)
+	}
+	tf.Close()
+	defer os.Remove(tf.Name())
+
+	if err := ls.Save(tf.Name()); err != nil {
+		t.Fatal(err)
+	}
+	got := todo.List{}
+	if err := got.Get(tf.Name()); err != nil {
+		t.Fatal(err)
+	}
+	if id := got.Add("New Task 4"); id != 4 {
+		t.Errorf("Expected ID 4 after reading the list, got %d instead.", id)
+	}
+}
+
+func TestList_GetAssignsIDs(t *testing.T) {
+	tf, err := ioutil.TempFile("", "")
+	if err != nil {
+		t.Fatalf("Error creating temp file: %s", err)
+	}
+	defer os.Remove(tf.Name())
+
+	// list saved before items had IDs
+	data := `[{"Task":"Task 1","Done":false},{"Task":"Task 2","Done":true}]`
+	if _, err := tf.WriteString(data); err != nil {
+		t.Fatal(err)
+	}
+	tf.Close()
+
+	ls := todo.List{}
+	if err := ls.Get(tf.Name()); err != nil {
+		t.Fatalf("Error getting list from file: %s", err)
+	}
+	for i, v := range ls.Items {
+		if v.ID != i+1 {
+			t.Errorf("Expected ID %d for %q, got %d instead.", i+1, v.Task, v.ID)
+		}
+	}
+}
+
+func TestList_Details(t *testing.T) {
+	ls := todo.List{}
+
+	id := ls.Add("New Task")
+	if err := ls.SetPriority(id, "a"); err != nil {
+		t.Fatal(err)
+	}
+	if err := ls.SetPriority(id, "AB"); err == nil {
+		t.Error("Expected error for invalid priority, got nil")
+	}
+	due := time.Date(2026, time.November, 1, 0, 0, 0, 0, time.Local)
+	if err := ls.SetDue(id, due); err != nil {
+		t.Fatal(err)
+	}
+	if err := ls.AddTags(id, "work", "home", "work"); err != nil {
+		t.Fatal(err)
+	}
+	ls.Add("Plain Task")
+
+	expected := "  1: New Task (A) due:2026-11-01 +home +work\n  2: Plain Task\n"
+	if ls.String() != expected {
+		t.Errorf("Expected %q, got %q instead.", expected, ls.String())
+	}
+	if !ls.Items[0].HasTag("Work") {
+		t.Error("Expected item to have tag \"Work\"")
+	}
+}
+
+func TestList_EditReopen(t *testing.T) {
+	ls := todo.List{}
+
+	id := ls.Add("New Tsak")
+	if err := ls.Edit(id, "New Task"); err != nil {
+		t.Fatal(err)
+	}
+	if ls.Items[0].Task != "New Task" {
+		t.Errorf("Expected %q, got %q instead.", "New Task", ls.Items[0].Task)
+	}
+	if err := ls.Edit(id, " "); err == nil {
+		t.Error("Expected error for blank task, got nil")
+	}
+
+	if err := ls.Reopen(id); !errors.Is(err, todo.ErrNotDone) {
+		t.Errorf("Expected ErrNotDone for an open task, got %v instead.", err)
+	}
+
+	ls.Complete(id)
+	if err := ls.Reopen(id); err != nil {
+		t.Fatal(err)
+	}
+	if ls.Items[0].Done || !ls.Items[0].CompletedAt.IsZero() {
+		t.Error("Expected reopened task not to be completed")
+	}
+}
+
+func TestList_Move(t *testing.T) {
+	testCases := []struct {
+		name     string
+		id       int
+		pos      int
+		expected string
+		expErr   bool
+	}{
+		{name: "Up", id: 4, pos: 2, expected: "1423"},
+		{name: "Down", id: 1, pos: 3, expected: "2314"},
+		{name: "Same", id: 2, pos: 2, expected: "1234"},
+		{name: "OutOfRange", id: 2, pos: 5, expErr: true},
+		{name: "NotFound", id: 5, pos: 1, expErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ls := todo.List{}
+			for i := 1; i <= 4; i++ {
+				ls.Add(fmt.Sprintf("Task %d", i))
+			}
+
+			err := ls.Move(tc.id, tc.pos)
+			if tc.expErr {
+				if err == nil {
+					t.Fatal("Expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			order := ""
+			for _, v := range ls.Items {
+				order += fmt.Sprint(v.ID)
+			}
+			if order != tc.expected {
+				t.Errorf("Expected order %q, got %q instead.", tc.expected, order)
+			}
+		})
+	}
+}
+
+func TestList_SetNotes(t *testing.T) {
+	ls := todo.List{}
+
+	id := ls.Add("New Task")
+	if err := ls.SetNotes(id, "\nFirst line.\nSecond line.\n\n"); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "First line.\nSecond line."
+	if ls.Items[0].Notes != expected {
+		t.Errorf("Expected %q, got %q instead.", expected, ls.Items[0].Notes)
+	}
+	if err := ls.SetNotes(2, "notes"); err == nil {
+		t.Error("Expected error for missing item, got nil")
 	}
 }
@@ -4,11 +4,16 @@ package cmd
 
 import (
 	"bytes"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"strconv"
+	"strings"
 	"testing"
+
+	"github.com/spf13/viper"
 )
 
 func TestListAction(t *testing.T) {
@@ -25,7 +30,7 @@ func TestListAction(t *testing.T) {
 		{
 			name:   "Results",
 			expErr: nil,
-			expOut: "-  1  Task 1\n-  2  Task 2\n",
+			expOut: "-  1  Task 1\n-  3  Task 2\n",
 			resp:   testResp["resultMany"],
 		},
 		{
@@ -158,7 +163,9 @@ func TestAddAction(t *testing.T) {
 
 			body, err := ioutil.ReadAll(r.Body)
 			if err != nil {
-				t.Fatal(err)
+				t.Error(err)
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
 			}
 			r.Body.Close()
 
@@ -247,3 +254,235 @@ func TestDelAction(t *testing.T) {
 		t.Errorf("Expected output %q, got %q", expOut, out.String())
 	}
 }
+
+func TestEditAction(t *testing.T) {
+	expURLPath := "/todo/1"
+	expMethod := http.MethodPatch
+	expBody := "{\"task\":\"Task one\"}\n"
+	expOut := "Item number 1 changed to \"Task one\".\n"
+
+	url, cleanup := mockServer(
+		func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != expURLPath {
+				t.Errorf("Expected path %q, got %q", expURLPath, r.URL.Path)
+			}
+			if r.Method != expMethod {
+				t.Errorf("Expected method %q, got %q", expMethod, r.Method)
+			}
+
+			body, err := ioutil.ReadAll(r.Body)
+			if err != nil {
+				t.Error(err)
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			r.Body.Close()
+
+			if string(body) != expBody {
+				t.Errorf("Expected body %q, got %q", expBody, string(body))
+			}
+
+			w.WriteHeader(testResp["noContent"].Status)
+			fmt.Fprintln(w, testResp["noContent"].Body)
+		})
+	defer cleanup()
+
+	var out bytes.Buffer
+	if err := editAction(&out, url, "1", []string{"Task", "one"}); err != nil {
+		t.Fatalf("Expected no error, got %q.", err)
+	}
+	if out.String() != expOut {
+		t.Errorf("Expected output %q, got %q", expOut, out.String())
+	}
+}
+
+func TestReopenAction(t *testing.T) {
+	expURLPath := "/todo/1"
+	expMethod := http.MethodPatch
+	expQuery := "reopen"
+	expOut := "Item number 1 reopened.\n"
+
+	url, cleanup := mockServer(
+		func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != expURLPath {
+				t.Errorf("Expected path %q, got %q", expURLPath, r.URL.Path)
+			}
+			if r.Method != expMethod {
+				t.Errorf("Expected method %q, got %q", expMethod, r.Method)
+			}
+			if _, ok := r.URL.Query()[expQuery]; !ok {
+				t.Errorf("Expected query %q not found in URL", expQuery)
+			}
+
+			w.WriteHeader(testResp["noContent"].Status)
+			fmt.Fprintln(w, testResp["noContent"].Body)
+		})
+	defer cleanup()
+
+	var out bytes.Buffer
+	if err := reopenAction(&out, url, "1"); err != nil {
+		t.Fatalf("Expected no error, got %q.", err)
+	}
+	if out.String() != expOut {
+		t.Errorf("Expected output %q, got %q", expOut, out.String())
+	}
+}
+
+func TestMoveAction(t *testing.T) {
+	expURLPath := "/todo/3"
+	expMethod := http.MethodPatch
+	expBody := "{\"position\":1}\n"
+	expOut := "Item number 3 moved to position 1.\n"
+
+	url, cleanup := mockServer(
+		func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != expURLPath {
+				t.Errorf("Expected path %q, got %q", expURLPath, r.URL.Path)
+			}
+			if r.Method != expMethod {
+				t.Errorf("Expected method %q, got %q", expMethod, r.Method)
+			}
+
+			body, err := ioutil.ReadAll(r.Body)
+			if err != nil {
+				t.Error(err)
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			r.Body.Close()
+
+			if string(body) != expBody {
+				t.Errorf("Expected body %q, got %q", expBody, string(body))
+			}
+
+			w.WriteHeader(testResp["noContent"].Status)
+			fmt.Fprintln(w, testResp["noContent"].Body)
+		})
+	defer cleanup()
+
+	var out bytes.Buffer
+	if err := moveAction(&out, url, "3", "1"); err != nil {
+		t.Fatalf("Expected no error, got %q.", err)
+	}
+	if out.String() != expOut {
+		t.Errorf("Expected output %q, got %q", expOut, out.String())
+	}
+
+	if err := moveAction(&out, url, "3", "top"); !errors.Is(err, ErrNotNumber) {
+		t.Errorf("Expected error %q, got %v.", ErrNotNumber, err)
+	}
+}
+
+func TestToken(t *testing.T) {
+	viper.Set("token", "secret")
+	defer viper.Set("token", "")
+
+	url, cleanup := mockServer(
+		func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") != "Bearer secret" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			if r.Method != http.MethodGet {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			w.WriteHeader(testResp["resultMany"].Status)
+			fmt.Fprintln(w, testResp["resultMany"].Body)
+		})
+	defer cleanup()
+
+	var out bytes.Buffer
+	if err := listAction(&out, url); err != nil {
+		t.Fatalf("Expected no error, got %q.", err)
+	}
+	if err := delAction(&out, url, "1"); !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("Expected error %q, got %v.", ErrUnauthorized, err)
+	}
+
+	viper.Set("token", "")
+	if err := listAction(&out, url); !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("Expected error %q, got %v.", ErrUnauthorized, err)
+	}
+}
+
+func TestErrorResponse(t *testing.T) {
+	testCases := []struct {
+		name   string
+		resp   string
+		expErr error
+		expMsg string
+	}{
+		{"NotFound", "notFoundError", ErrNotFound, "not found: ID 3 not found (request c0ffee)"},
+		{"Invalid", "invalidError", ErrInvalid, "invalid data: Invalid JSON (request c0ffee)"},
+		{"PlainText", "notFound", ErrNotFound, "not found: 404 - not found\n"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			url, cleanup := mockServer(
+				func(w http.ResponseWriter, r *http.Request) {
+					w.WriteHeader(testResp[tc.resp].Status)
+					fmt.Fprintln(w, testResp[tc.resp].Body)
+				})
+			defer cleanup()
+
+			var out bytes.Buffer
+			for _, err := range []error{
+				viewAction(&out, url, "3"),
+				editAction(&out, url, "3", []string{"Task"}),
+			} {
+				if !errors.Is(err, tc.expErr) {
+					t.Fatalf("Expected error %q, got %v.", tc.expErr, err)
+				}
+				if err.Error() != tc.expMsg {
+					t.Errorf("Expected message %q, got %q.", tc.expMsg, err.Error())
+				}
+			}
+		})
+	}
+}
+
+func TestListActionPages(t *testing.T) {
+	const total = 250
+	requests := 0
+
+	url, cleanup := mockServer(
+		func(w http.ResponseWriter, r *http.Request) {
+			requests++
+			q := r.URL.Query()
+			// the handler runs in the server goroutine, where the
+			// test can't be stopped with t.Fatal
+			limit, err := strconv.Atoi(q.Get("limit"))
+			if err != nil {
+				t.Errorf("Expected a limit, got %q", q.Get("limit"))
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			offset, err := strconv.Atoi(q.Get("offset"))
+			if err != nil {
+				t.Errorf("Expected an offset, got %q", q.Get("offset"))
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			resp := response{TotalResults: total}
+			for id := offset + 1; id <= total && id <= offset+limit; id++ {
+				resp.Results = append(resp.Results, item{ID: id, Task: fmt.Sprintf("Task %d", id)})
+			}
+			json.NewEncoder(w).Encode(resp)
+		})
+	defer cleanup()
+
+	var out bytes.Buffer
+	if err := listAction(&out, url); err != nil {
+		t.Fatalf("Expected no error, got %q.", err)
+	}
+	if requests != 3 {
+		t.Errorf("Expected 3 requests, got %d", requests)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != total || !strings.Contains(lines[total-1], "Task 250") {
+		t.Errorf("Expected %d items, got %d:\n%s", total, len(lines), out.String())
+	}
+}
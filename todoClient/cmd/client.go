@@ -9,6 +9,8 @@ import (
 	"io/ioutil"
 	"net/http"
 	"time"
+
+	"github.com/spf13/viper"
 )
 
 var (
@@ -17,10 +19,12 @@ var (
 	ErrInvalidResponse = errors.New("invalid server response")
 	ErrInvalid         = errors.New("invalid data")
 	ErrNotNumber       = errors.New("not a number")
+	ErrUnauthorized    = errors.New("unauthorized")
 )
 
 type (
 	item struct {
+		ID          int
 		Task        string
 		Done        bool
 		CreateAt    time.Time
@@ -39,27 +43,73 @@ func newClient() *http.Client {
 	}
 }
 
-func getItems(url string) ([]item, error) {
-	r, err := newClient().Get(url)
+// newRequest creates a request authenticated with
+// the API token, when one is set
+func newRequest(method, url string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequest(method, url, body)
 	if err != nil {
-		return nil, fmt.Errorf("%w: %s", ErrConnection, err)
+		return nil, err
 	}
-	defer r.Body.Close()
+	if token := viper.GetString("token"); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return req, nil
+}
 
-	if r.StatusCode != http.StatusOK {
-		msg, err := ioutil.ReadAll(r.Body)
-		if err != nil {
-			return nil, fmt.Errorf("cannot read body: %w", err)
+// apiError is the error the server replies with
+type apiError struct {
+	Code      string `json:"code"`
+	Message   string `json:"message"`
+	Details   string `json:"details"`
+	RequestID string `json:"requestId"`
+}
+
+// errorsByCode maps the server error codes to the client errors
+var errorsByCode = map[string]error{
+	"not_found":    ErrNotFound,
+	"invalid_data": ErrInvalid,
+	"unauthorized": ErrUnauthorized,
+	"forbidden":    ErrUnauthorized,
+}
+
+// statusError returns the error for an unexpected response status,
+// from the error envelope of the server when there's one
+func statusError(r *http.Response) error {
+	msg, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return fmt.Errorf("cannot read body: %w", err)
+	}
+
+	var resp struct {
+		Error *apiError `json:"error"`
+	}
+	if json.Unmarshal(msg, &resp) == nil && resp.Error != nil && resp.Error.Code != "" {
+		e := resp.Error
+		err, ok := errorsByCode[e.Code]
+		if !ok {
+			err = ErrInvalidResponse
 		}
-		err = ErrInvalidResponse
-		if r.StatusCode == http.StatusNotFound {
-			err = ErrNotFound
+		text := e.Message
+		if e.Details != "" {
+			text = e.Details
 		}
-		return nil, fmt.Errorf("%w: %s", err, msg)
+		return fmt.Errorf("%w: %s (request %s)", err, text, e.RequestID)
 	}
 
-	var resp response
-	if err := json.NewDecoder(r.Body).Decode(&resp); err != nil {
+	switch r.StatusCode {
+	case http.StatusNotFound:
+		err = ErrNotFound
+	case http.StatusUnauthorized, http.StatusForbidden:
+		err = ErrUnauthorized
+	default:
+		err = ErrInvalidResponse
+	}
+	return fmt.Errorf("%w: %s", err, msg)
+}
+
+func getItems(url string) ([]item, error) {
+	resp, err := getPage(url)
+	if err != nil {
 		return nil, err
 	}
 
@@ -70,9 +120,55 @@ func getItems(url string) ([]item, error) {
 	return resp.Results, nil
 }
 
+// getPage gets a response with a page of items
+func getPage(url string) (response, error) {
+	var resp response
+
+	req, err := newRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return resp, err
+	}
+
+	r, err := newClient().Do(req)
+	if err != nil {
+		return resp, fmt.Errorf("%w: %s", ErrConnection, err)
+	}
+	defer r.Body.Close()
+
+	if r.StatusCode != http.StatusOK {
+		return resp, statusError(r)
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&resp); err != nil {
+		return resp, err
+	}
+	return resp, nil
+}
+
+// number of items asked for in each request
+const pageSize = 100
+
+// getAll gets the items of all the pages, one request per page
 func getAll(apiRoot string) ([]item, error) {
-	u := fmt.Sprintf("%s/todo", apiRoot)
-	return getItems(u)
+	var items []item
+	for {
+		u := fmt.Sprintf("%s/todo?limit=%d&offset=%d", apiRoot, pageSize, len(items))
+		resp, err := getPage(u)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, resp.Results...)
+
+		// servers without pages give all the items at once
+		if len(resp.Results) == 0 || len(items) >= resp.TotalResults {
+			break
+		}
+	}
+
+	if len(items) == 0 {
+		return nil, fmt.Errorf("%w: No results found", ErrNotFound)
+	}
+	return items, nil
 }
 
 func getOne(apiRoot string, id int) (item, error) {
@@ -90,7 +186,7 @@ func getOne(apiRoot string, id int) (item, error) {
 const timeFormat = "Jan/02 @15:04"
 
 func sendRequest(url, method, contentType string, expStatus int, body io.Reader) error {
-	req, err := http.NewRequest(method, url, body)
+	req, err := newRequest(method, url, body)
 	if err != nil {
 		return err
 	}
@@ -105,15 +201,7 @@ func sendRequest(url, method, contentType string, expStatus int, body io.Reader)
 	defer r.Body.Close()
 
 	if r.StatusCode != expStatus {
-		msg, err := ioutil.ReadAll(r.Body)
-		if err != nil {
-			return fmt.Errorf("cannot read body: %w", err)
-		}
-		err = ErrInvalidResponse
-		if r.StatusCode == http.StatusNotFound {
-			err = ErrNotFound
-		}
-		return fmt.Errorf("%w: %s", err, msg)
+		return statusError(r)
 	}
 	return nil
 }
@@ -144,3 +232,38 @@ func deleteItem(apiRoot string, id int) error {
 	u := fmt.Sprintf("%s/todo/%d", apiRoot, id)
 	return sendRequest(u, http.MethodDelete, "", http.StatusNoContent, nil)
 }
+
+func reopenItem(apiRoot string, id int) error {
+	u := fmt.Sprintf("%s/todo/%d?reopen", apiRoot, id)
+	return sendRequest(u, http.MethodPatch, "", http.StatusNoContent, nil)
+}
+
+func editItem(apiRoot string, id int, task string) error {
+	changes := struct {
+		Task string `json:"task"`
+	}{
+		Task: task,
+	}
+	return patchItem(apiRoot, id, changes)
+}
+
+func moveItem(apiRoot string, id, position int) error {
+	changes := struct {
+		Position int `json:"position"`
+	}{
+		Position: position,
+	}
+	return patchItem(apiRoot, id, changes)
+}
+
+func patchItem(apiRoot string, id int, changes interface{}) error {
+	u := fmt.Sprintf("%s/todo/%d", apiRoot, id)
+
+	var body bytes.Buffer
+	if err := json.NewEncoder(&body).Encode(changes); err != nil {
+		return err
+	}
+
+	return sendRequest(u, http.MethodPatch, "application/json",
+		http.StatusNoContent, &body)
+}
@@ -0,0 +1,111 @@
+//go:build !integration
+
+package cmd
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"todo/api/apitest"
+)
+
+// contractServer replies to the requests checked against the
+// OpenAPI document with the given responses by operation ID
+func contractServer(t *testing.T, doc *apitest.Document,
+	replies map[string]string) (string, func()) {
+	t.Helper()
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := doc.ValidateRequest(r); err != nil {
+			t.Error(err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		op, _, _ := doc.Find(r.Method, r.URL.Path)
+		resp, ok := testResp[replies[op.OperationID]]
+		if !ok {
+			t.Errorf("Unexpected operation %s", op.OperationID)
+			w.WriteHeader(http.StatusNotImplemented)
+			return
+		}
+		if r.Method == http.MethodPost {
+			w.Header().Set("Location", "/todo/3")
+		}
+		if resp.Body != "" {
+			w.Header().Set("Content-Type", "application/json")
+		}
+		w.WriteHeader(resp.Status)
+		w.Write([]byte(resp.Body))
+	}))
+	return ts.URL, ts.Close
+}
+
+func TestContract_Requests(t *testing.T) {
+	doc, err := apitest.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	url, cleanup := contractServer(t, doc, map[string]string{
+		"getItems":   "resultMany",
+		"getItem":    "resultOne",
+		"addItem":    "created",
+		"updateItem": "noContent",
+		"deleteItem": "noContent",
+	})
+	defer cleanup()
+
+	actions := map[string]func() error{
+		"list":     func() error { return listAction(&bytes.Buffer{}, url) },
+		"view":     func() error { return viewAction(&bytes.Buffer{}, url, "1") },
+		"add":      func() error { return addAction(&bytes.Buffer{}, url, []string{"Task", "1"}) },
+		"complete": func() error { return completeAction(&bytes.Buffer{}, url, "1") },
+		"reopen":   func() error { return reopenAction(&bytes.Buffer{}, url, "1") },
+		"edit":     func() error { return editAction(&bytes.Buffer{}, url, "1", []string{"Task"}) },
+		"move":     func() error { return moveAction(&bytes.Buffer{}, url, "3", "1") },
+		"del":      func() error { return delAction(&bytes.Buffer{}, url, "1") },
+	}
+	for name, action := range actions {
+		t.Run(name, func(t *testing.T) {
+			if err := action(); err != nil {
+				t.Errorf("Expected no error, got %q.", err)
+			}
+		})
+	}
+}
+
+// the fixtures of the tests are responses the server can give
+func TestContract_Responses(t *testing.T) {
+	doc, err := apitest.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		resp   string
+		method string
+		path   string
+	}{
+		{"resultMany", http.MethodGet, "/todo"},
+		{"resultOne", http.MethodGet, "/todo/1"},
+		{"noResults", http.MethodGet, "/todo"},
+		{"notFoundError", http.MethodGet, "/todo/3"},
+		{"invalidError", http.MethodPatch, "/todo/3"},
+		{"created", http.MethodPost, "/todo"},
+		{"noContent", http.MethodDelete, "/todo/1"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.resp, func(t *testing.T) {
+			resp := testResp[tc.resp]
+			header := http.Header{}
+			header.Set("Content-Type", "application/json")
+			header.Set("X-Request-ID", "c0ffee")
+			header.Set("Location", "/todo/3")
+			if err := doc.ValidateResponse(tc.method, tc.path, resp.Status, header, []byte(resp.Body)); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
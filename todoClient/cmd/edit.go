@@ -0,0 +1,51 @@
+/*
+Copyright © 2023 youngzy
+Copyrights apply to this source code.
+Check LICENSE for details.
+
+*/
+package cmd
+
+import (
+	"fmt"
+	"github.com/spf13/viper"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// editCmd represents the edit command
+var editCmd = &cobra.Command{
+	Use:          "edit <id> <task>",
+	Short:        "Replaces the task of an item",
+	SilenceUsage: true,
+	Args:         cobra.MinimumNArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		apiRoot := viper.GetString("api-root")
+		return editAction(os.Stdout, apiRoot, args[0], args[1:])
+	},
+}
+
+func editAction(out io.Writer, apiRoot string, arg string, args []string) error {
+	id, err := strconv.Atoi(arg)
+	if err != nil {
+		return fmt.Errorf("%w: Item id must be a number", ErrNotNumber)
+	}
+	task := strings.Join(args, " ")
+	if err := editItem(apiRoot, id, task); err != nil {
+		return err
+	}
+	return printEdit(out, id, task)
+}
+
+func printEdit(out io.Writer, id int, task string) error {
+	_, err := fmt.Fprintf(out, "Item number %d changed to %q.\n", id, task)
+	return err
+}
+
+func init() {
+	rootCmd.AddCommand(editCmd)
+}
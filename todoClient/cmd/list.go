@@ -37,12 +37,12 @@ func listAction(out io.Writer, apiRoot string) error {
 func printAll(out io.Writer, items []item) error {
 	w := tabwriter.NewWriter(out, 3, 2, 0, ' ', 0)
 
-	for k, v := range items {
+	for _, v := range items {
 		done := "-"
 		if v.Done {
 			done = "X"
 		}
-		fmt.Fprintf(w, "%s\t%d\t%s\t\n", done, k+1, v.Task)
+		fmt.Fprintf(w, "%s\t%d\t%s\t\n", done, v.ID, v.Task)
 	}
 
 	return w.Flush()
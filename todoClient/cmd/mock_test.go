@@ -14,34 +14,63 @@ var testResp = map[string]struct {
 		Body: `{
 "results": [
 {
+"ID": 1,
 "Task": "Task 1",
 "Done": false,
 "CreateAt": "2023-01-12T17:00:51.3695194+08:00",
-"Completed": "0001-01-01T00:00:00Z"
+"CompletedAt": "0001-01-01T00:00:00Z",
+"Priority": "",
+"Due": "0001-01-01T00:00:00Z",
+"Tags": null,
+"Notes": "",
+"Recur": "",
+"Parent": 0,
+"BlockedBy": null,
+"ListName": ""
 },
 {
+"ID": 3,
 "Task": "Task 2",
 "Done": false,
 "CreateAt": "2023-01-12T17:00:51.3695194+08:00",
-"CompletedAt": "0001-01-01T00:00:00Z"
+"CompletedAt": "0001-01-01T00:00:00Z",
+"Priority": "",
+"Due": "0001-01-01T00:00:00Z",
+"Tags": null,
+"Notes": "",
+"Recur": "",
+"Parent": 0,
+"BlockedBy": null,
+"ListName": ""
 }
 ],
 "date": 0,
-"totalResults": 2
+"totalResults": 2,
+"offset": 0
 }`},
 	"resultOne": {
 		Status: http.StatusOK,
 		Body: `{
 "results": [
 {
+"ID": 1,
 "Task": "Task 1",
 "Done": false,
 "CreateAt": "2023-01-12T17:00:51.3695194+08:00",
-"CompletedAt": "0001-01-01T00:00:00Z"
+"CompletedAt": "0001-01-01T00:00:00Z",
+"Priority": "",
+"Due": "0001-01-01T00:00:00Z",
+"Tags": null,
+"Notes": "",
+"Recur": "",
+"Parent": 0,
+"BlockedBy": null,
+"ListName": ""
 }
 ],
 "date": 1572265440,
-"totalResults": 1
+"totalResults": 1,
+"offset": 0
 }`,
 	},
 	"noResults": {
@@ -49,7 +78,8 @@ var testResp = map[string]struct {
 		Body: `{
 "results": [],
 "date": 0,
-"totalResults": 0
+"totalResults": 0,
+"offset": 0
 }`,
 	},
 	"root": {
@@ -60,6 +90,16 @@ var testResp = map[string]struct {
 		Status: http.StatusNotFound,
 		Body:   "404 - not found",
 	},
+	"notFoundError": {
+		Status: http.StatusNotFound,
+		Body: `{"error": {"code": "not_found", "message": "Not Found",
+"details": "ID 3 not found", "requestId": "c0ffee"}}`,
+	},
+	"invalidError": {
+		Status: http.StatusBadRequest,
+		Body: `{"error": {"code": "invalid_data", "message": "Bad Request",
+"details": "Invalid JSON", "requestId": "c0ffee"}}`,
+	},
 	"created": {
 		Status: http.StatusCreated,
 		Body:   "",
@@ -0,0 +1,53 @@
+/*
+Copyright © 2023 youngzy
+Copyrights apply to this source code.
+Check LICENSE for details.
+
+*/
+package cmd
+
+import (
+	"fmt"
+	"github.com/spf13/viper"
+	"io"
+	"os"
+	"strconv"
+
+	"github.com/spf13/cobra"
+)
+
+// moveCmd represents the move command
+var moveCmd = &cobra.Command{
+	Use:          "move <id> <position>",
+	Short:        "Moves an item to a new position in the list",
+	SilenceUsage: true,
+	Args:         cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		apiRoot := viper.GetString("api-root")
+		return moveAction(os.Stdout, apiRoot, args[0], args[1])
+	},
+}
+
+func moveAction(out io.Writer, apiRoot string, arg, posArg string) error {
+	id, err := strconv.Atoi(arg)
+	if err != nil {
+		return fmt.Errorf("%w: Item id must be a number", ErrNotNumber)
+	}
+	pos, err := strconv.Atoi(posArg)
+	if err != nil {
+		return fmt.Errorf("%w: Position must be a number", ErrNotNumber)
+	}
+	if err := moveItem(apiRoot, id, pos); err != nil {
+		return err
+	}
+	return printMove(out, id, pos)
+}
+
+func printMove(out io.Writer, id, pos int) error {
+	_, err := fmt.Fprintf(out, "Item number %d moved to position %d.\n", id, pos)
+	return err
+}
+
+func init() {
+	rootCmd.AddCommand(moveCmd)
+}
@@ -0,0 +1,49 @@
+/*
+Copyright © 2023 youngzy
+Copyrights apply to this source code.
+Check LICENSE for details.
+
+*/
+package cmd
+
+import (
+	"fmt"
+	"github.com/spf13/viper"
+	"io"
+	"os"
+	"strconv"
+
+	"github.com/spf13/cobra"
+)
+
+// reopenCmd represents the reopen command
+var reopenCmd = &cobra.Command{
+	Use:          "reopen <id>",
+	Short:        "Marks a completed item as not done",
+	SilenceUsage: true,
+	Args:         cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		apiRoot := viper.GetString("api-root")
+		return reopenAction(os.Stdout, apiRoot, args[0])
+	},
+}
+
+func reopenAction(out io.Writer, apiRoot string, arg string) error {
+	id, err := strconv.Atoi(arg)
+	if err != nil {
+		return fmt.Errorf("%w: Item id must be a number", ErrNotNumber)
+	}
+	if err := reopenItem(apiRoot, id); err != nil {
+		return err
+	}
+	return printReopen(out, id)
+}
+
+func printReopen(out io.Writer, id int) error {
+	_, err := fmt.Fprintf(out, "Item number %d reopened.\n", id)
+	return err
+}
+
+func init() {
+	rootCmd.AddCommand(reopenCmd)
+}
@@ -44,11 +44,14 @@ func init() {
 
 	rootCmd.PersistentFlags().String("api-root",
 		"http://localhost:8080", "Todo API URL")
+	rootCmd.PersistentFlags().String("token", "",
+		"Todo API token, also read from TODO_TOKEN")
 
 	replacer := strings.NewReplacer("-", "_")
 	viper.SetEnvKeyReplacer(replacer)
 	viper.SetEnvPrefix("TODO")
 	viper.BindPFlag("api-root", rootCmd.PersistentFlags().Lookup("api-root"))
+	viper.BindPFlag("token", rootCmd.PersistentFlags().Lookup("token"))
 
 }
 
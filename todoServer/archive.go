@@ -0,0 +1,85 @@
+package main
+
+import (
+	"log"
+	"time"
+	"todo"
+)
+
+// scheduleArchive moves the items completed more than the given number
+// of days ago from store to archive every interval, or deletes them when
+// archive is nil, until the returned stop function is called
+func scheduleArchive(store *listStore, archive todo.Storage, days int,
+	interval time.Duration) (stop func()) {
+	done := make(chan struct{})
+	finished := make(chan struct{})
+
+	go func() {
+		defer close(finished)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				runArchive(store, archive, days)
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		<-finished
+	}
+}
+
+func runArchive(store *listStore, archive todo.Storage, days int) {
+	op := "Archive"
+	if archive == nil {
+		op = "Purge"
+	}
+
+	n, err := archiveItems(store, archive, time.Now().AddDate(0, 0, -days))
+	if err != nil {
+		log.Printf("%s: Error: %s", op, err)
+		return
+	}
+	log.Printf("%s: %d items", op, n)
+}
+
+// archiveItems moves the items completed before cutoff to archive,
+// or deletes them when archive is nil, returning how many. It goes
+// through the store so it keeps up with the list, and the items are
+// only removed once they're archived. They're taken out of the archive
+// again when the list can't be saved
+func archiveItems(store *listStore, archive todo.Storage, cutoff time.Time) (int, error) {
+	var (
+		n   int
+		err error
+	)
+	uerr := store.update(func(list *todo.List, save func() error) {
+		old := list.Archive(cutoff)
+		if len(old.Items) == 0 {
+			return
+		}
+		if archive != nil {
+			if err = todo.AppendArchive(archive, old); err != nil {
+				return
+			}
+		}
+		if err = save(); err != nil {
+			if archive != nil {
+				err = todo.RemoveArchived(archive, old, err)
+			}
+			return
+		}
+		n = len(old.Items)
+	})
+	if uerr != nil {
+		return 0, uerr
+	}
+	return n, err
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"todo"
+)
+
+// user is the identity an API token authenticates
+type user struct {
+	name     string
+	readOnly bool
+}
+
+// tokens maps the SHA-256 hashes of the API tokens to their users,
+// so the tokens themselves aren't kept in memory
+type tokens map[string]user
+
+var validUserName = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// loadTokens reads a token file with one "TOKEN USER [read-only]"
+// line per token. Blank lines and lines starting with # are skipped.
+// A user may have several tokens
+func loadTokens(filename string) (tokens, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	t := tokens{}
+	s := bufio.NewScanner(f)
+	for n := 1; s.Scan(); n++ {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) < 2 || len(fields) > 3 ||
+			(len(fields) == 3 && fields[2] != "read-only") {
+			return nil, fmt.Errorf("%s:%d: expected TOKEN USER [read-only]", filename, n)
+		}
+		if !validUserName.MatchString(fields[1]) {
+			return nil, fmt.Errorf("%s:%d: invalid user name %q: only letters, digits, _ and - are allowed",
+				filename, n, fields[1])
+		}
+		hash := tokenHash(fields[0])
+		if _, ok := t[hash]; ok {
+			return nil, fmt.Errorf("%s:%d: duplicate token", filename, n)
+		}
+		t[hash] = user{name: fields[1], readOnly: len(fields) == 3}
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	if len(t) == 0 {
+		return nil, fmt.Errorf("%s: no tokens", filename)
+	}
+	return t, nil
+}
+
+// users returns the names of the users with a token, sorted
+func (t tokens) users() []string {
+	seen := map[string]bool{}
+	var names []string
+	for _, u := range t {
+		if !seen[u.name] {
+			seen[u.name] = true
+			names = append(names, u.name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func tokenHash(token string) string {
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(token)))
+}
+
+// userSpec returns the storage spec of a user's list, adding the
+// user name before the extension of the file in spec, such as
+// todoServer.alice.json for todoServer.json. In-memory storage
+// is already separate for every user
+func userSpec(spec, name string) string {
+	kind, file := "", spec
+	if kv := strings.SplitN(spec, ":", 2); len(kv) == 2 {
+		switch strings.ToLower(kv[0]) {
+		case todo.StorageMemory:
+			return spec
+		case todo.StorageJSON, todo.StorageSQLite, "sqlite3":
+			kind, file = kv[0]+":", kv[1]
+		}
+	}
+
+	ext := filepath.Ext(file)
+	return kind + strings.TrimSuffix(file, ext) + "." + name + ext
+}
+
+// newAuthMux authenticates the requests with a bearer token and
+// serves them with the handler of the user, from handlers by user
+// name. Read-only users can only get their lists
+func newAuthMux(t tokens, handlers map[string]http.Handler) http.Handler {
+	return withRequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/":
+			rootHandler(w, r)
+			return
+		case "/openapi.json":
+			openAPIHandler(w, r)
+			return
+		}
+
+		auth := r.Header.Get("Authorization")
+		token := strings.TrimSpace(strings.TrimPrefix(auth, "Bearer "))
+		u, ok := t[tokenHash(token)]
+		if !strings.HasPrefix(auth, "Bearer ") || token == "" || !ok {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="todo"`)
+			replyError(w, r, http.StatusUnauthorized, "Missing or invalid token")
+			return
+		}
+		if u.readOnly && r.Method != http.MethodGet && r.Method != http.MethodHead {
+			replyError(w, r, http.StatusForbidden,
+				fmt.Sprintf("User %s is read-only", u.name))
+			return
+		}
+
+		h, ok := handlers[u.name]
+		if !ok {
+			replyError(w, r, http.StatusForbidden,
+				fmt.Sprintf("No list for user %s", u.name))
+			return
+		}
+		h.ServeHTTP(w, r)
+	}))
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"todo"
+	"todo/api"
+	"todo/api/apitest"
+)
+
+// contractTransport checks the requests and responses
+// against the OpenAPI document, recording the operations
+type contractTransport struct {
+	t   *testing.T
+	doc *apitest.Document
+	ops map[string]bool
+}
+
+func (c *contractTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	c.t.Helper()
+
+	// the requests of the error cases may break the contract
+	if err := c.doc.ValidateRequest(r); err == nil {
+		op, _, _ := c.doc.Find(r.Method, r.URL.Path)
+		c.ops[op.OperationID] = true
+	}
+
+	resp, err := http.DefaultTransport.RoundTrip(r)
+	if err != nil {
+		return nil, err
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	if err := c.doc.ValidateResponse(r.Method, r.URL.Path, resp.StatusCode, resp.Header, body); err != nil {
+		c.t.Error(err)
+	}
+	return resp, nil
+}
+
+func TestContract(t *testing.T) {
+	doc, err := apitest.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store, err := todo.OpenStorage("memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts := httptest.NewServer(newMux(loadStore(t, store)))
+	defer ts.Close()
+
+	tr := &contractTransport{t: t, doc: doc, ops: map[string]bool{}}
+	client := &http.Client{Transport: tr}
+
+	testCases := []struct {
+		method  string
+		path    string
+		body    string
+		expCode int
+	}{
+		{http.MethodGet, "/", "", http.StatusOK},
+		{http.MethodGet, "/openapi.json", "", http.StatusOK},
+		{http.MethodPost, "/todo", `{"task":"Buy milk"}`, http.StatusCreated},
+		{http.MethodPost, "/todo", `{"task":"Call mom","blockedBy":[1]}`, http.StatusCreated},
+		{http.MethodPost, "/todo", `{"task":`, http.StatusBadRequest},
+		{http.MethodGet, "/todo", "", http.StatusOK},
+		{http.MethodGet, "/todo?done=false&sort=-id&limit=1&offset=1", "", http.StatusOK},
+		{http.MethodGet, "/todo?q=milk&match=word", "", http.StatusOK},
+		{http.MethodGet, "/todo?limit=0", "", http.StatusBadRequest},
+		{http.MethodGet, "/todo/1", "", http.StatusOK},
+		{http.MethodGet, "/todo/9", "", http.StatusNotFound},
+		{http.MethodGet, "/todo/abc", "", http.StatusBadRequest},
+		{http.MethodPatch, "/todo/2?complete", "", http.StatusConflict},
+		{http.MethodPatch, "/todo/1?complete", "", http.StatusNoContent},
+		{http.MethodPatch, "/todo/1?reopen", "", http.StatusNoContent},
+		{http.MethodPatch, "/todo/1", `{"task":"Buy oat milk","position":2}`, http.StatusNoContent},
+		{http.MethodPatch, "/todo/1", `{"parent":1}`, http.StatusBadRequest},
+		{http.MethodPut, "/todo/1", `{"task":"Buy milk","done":true}`, http.StatusNoContent},
+		{http.MethodPut, "/todo/1", `{}`, http.StatusBadRequest},
+		{http.MethodPatch, "/todo/2", `{"list":"home"}`, http.StatusNoContent},
+		{http.MethodGet, "/lists", "", http.StatusOK},
+		{http.MethodPost, "/lists/work/todo", `{"task":"Write report"}`, http.StatusCreated},
+		{http.MethodGet, "/lists/work/todo", "", http.StatusOK},
+		{http.MethodGet, "/lists/work/todo/3", "", http.StatusOK},
+		{http.MethodGet, "/lists/work/todo/1", "", http.StatusNotFound},
+		{http.MethodPatch, "/lists/work/todo/3?complete", "", http.StatusNoContent},
+		{http.MethodPut, "/lists/work/todo/3", `{"task":"Write the report"}`, http.StatusNoContent},
+		{http.MethodDelete, "/lists/work/todo/3", "", http.StatusNoContent},
+		{http.MethodDelete, "/todo/1", "", http.StatusNoContent},
+		{http.MethodDelete, "/todo/1", "", http.StatusNotFound},
+	}
+
+	for _, tc := range testCases {
+		req, err := http.NewRequest(tc.method, ts.URL+tc.path, strings.NewReader(tc.body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tc.body != "" {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		r, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r.Body.Close()
+		if r.StatusCode != tc.expCode {
+			t.Errorf("%s %s: Expected %q, got %q.", tc.method, tc.path,
+				http.StatusText(tc.expCode), http.StatusText(r.StatusCode))
+		}
+	}
+
+	// every operation is checked with a valid request
+	for _, op := range doc.Operations() {
+		if !tr.ops[op] {
+			t.Errorf("Operation %s not checked", op)
+		}
+	}
+}
+
+func TestContract_Auth(t *testing.T) {
+	doc, err := apitest.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store, err := todo.OpenStorage("memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tokens := tokens{tokenHash("secret-r"): user{name: "alice", readOnly: true}}
+	ts := httptest.NewServer(newAuthMux(tokens, map[string]http.Handler{"alice": newMux(loadStore(t, store))}))
+	defer ts.Close()
+
+	client := &http.Client{Transport: &contractTransport{t: t, doc: doc, ops: map[string]bool{}}}
+	for _, tc := range []struct {
+		method, path, token string
+		expCode             int
+	}{
+		{http.MethodGet, "/openapi.json", "", http.StatusOK},
+		{http.MethodGet, "/todo", "", http.StatusUnauthorized},
+		{http.MethodGet, "/todo", "secret-r", http.StatusOK},
+		{http.MethodDelete, "/todo/1", "secret-r", http.StatusForbidden},
+	} {
+		req, err := http.NewRequest(tc.method, ts.URL+tc.path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tc.token != "" {
+			req.Header.Set("Authorization", "Bearer "+tc.token)
+		}
+		r, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r.Body.Close()
+		if r.StatusCode != tc.expCode {
+			t.Errorf("%s %s: Expected %q, got %q.", tc.method, tc.path,
+				http.StatusText(tc.expCode), http.StatusText(r.StatusCode))
+		}
+	}
+}
+
+func TestOpenAPIHandler(t *testing.T) {
+	url, cleanup := setupAPI(t)
+	defer cleanup()
+
+	r, err := http.Get(url + "/openapi.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Body.Close()
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.StatusCode != http.StatusOK || !bytes.Equal(body, api.Spec) {
+		t.Errorf("Expected the OpenAPI document, got %q: %.40q", r.Status, body)
+	}
+}
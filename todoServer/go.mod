@@ -2,6 +2,16 @@ module todoServer
 
 go 1.17
 
-require todo v0.1.0
+require (
+	todo v0.1.0
+	todo/api v0.0.0
+)
+
+require (
+	github.com/mattn/go-sqlite3 v1.14.16 // indirect
+	golang.org/x/crypto v0.5.0 // indirect
+)
 
 replace todo => ../todo
+
+replace todo/api => ../todo/api
@@ -5,9 +5,11 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
 	"strconv"
-	"sync"
+	"strings"
 	"todo"
+	"todo/api"
 )
 
 func rootHandler(writer http.ResponseWriter, request *http.Request) {
@@ -21,60 +23,132 @@ func rootHandler(writer http.ResponseWriter, request *http.Request) {
 	replyTextContent(writer, request, http.StatusOK, content)
 }
 
+// openAPIHandler serves the OpenAPI document of the API
+func openAPIHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(api.Spec)
+}
+
 var (
 	ErrNotFound    = errors.New("not found")
 	ErrInvalidData = errors.New("invalid data")
 )
 
-func todoRouter(todoFile string, lck sync.Locker) http.HandlerFunc {
+// todoRouter serves the items of the default list
+func todoRouter(store *listStore) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		list := &todo.List{}
-
-		lck.Lock()
-		defer lck.Unlock()
-		if err := list.Get(todoFile); err != nil {
-			replyError(w, r, http.StatusInternalServerError, err.Error())
-			return
-		}
+		serveList(w, r, store, todo.DefaultList, r.URL.Path)
+	}
+}
 
-		// handle the /todo root path
+// listsRouter serves the named lists with their item counts, and
+// the items of a named list under the {name}/todo path
+func listsRouter(store *listStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
 		if r.URL.Path == "" {
-			switch r.Method {
-			case http.MethodGet:
-				getAllHandler(w, r, list)
-			case http.MethodPost:
-				addHandler(w, r, list, todoFile)
-			default:
+			if !isRead(r) {
 				message := "Method not supported"
 				replyError(w, r, http.StatusMethodNotAllowed, message)
+				return
 			}
+			getListsHandler(w, r, store)
 			return
 		}
 
-		id, err := validateID(r.URL.Path, list)
-		if err != nil {
-			if errors.Is(err, ErrNotFound) {
-				replyError(w, r, http.StatusNotFound, err.Error())
-				return
-			}
+		parts := strings.SplitN(r.URL.Path, "/", 3)
+		if len(parts) < 2 || parts[1] != "todo" {
+			replyError(w, r, http.StatusNotFound, "")
+			return
+		}
+		if err := todo.ValidateListName(parts[0]); err != nil {
 			replyError(w, r, http.StatusBadRequest, err.Error())
 			return
 		}
+
+		path := ""
+		if len(parts) == 3 {
+			path = parts[2]
+		}
+		serveList(w, r, store, parts[0], path)
+	}
+}
+
+// serveList handles the requests for the items of the named list,
+// where path is the item ID or empty for the whole list. Only the
+// requests that may change the list wait for each other. Responses
+// are buffered and only sent once the list is unlocked, so slow
+// clients don't hold the lock
+func serveList(w http.ResponseWriter, r *http.Request,
+	store *listStore, name, path string) {
+	resp := newResponseBuffer(w)
+	var err error
+	if isRead(r) {
+		err = store.read(func(list *todo.List) {
+			serveItems(resp, r, list, name, path, nil)
+		})
+	} else {
+		err = store.update(func(list *todo.List, save func() error) {
+			serveItems(resp, r, list, name, path, save)
+		})
+	}
+	if err != nil {
+		replyError(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+	resp.writeTo(w)
+}
+
+// isRead reports whether the request only reads, which HEAD requests
+// do like GET ones, without the response body
+func isRead(r *http.Request) bool {
+	return r.Method == http.MethodGet || r.Method == http.MethodHead
+}
+
+// serveItems handles the requests for the items of the named list,
+// with save storing the changes made to list
+func serveItems(w http.ResponseWriter, r *http.Request,
+	list *todo.List, name, path string, save func() error) {
+	// handle the todo root path
+	if path == "" {
 		switch r.Method {
-		case http.MethodGet:
-			getOneHandler(w, r, list, id)
-		case http.MethodDelete:
-			deleteHandler(w, r, list, id, todoFile)
-		case http.MethodPatch:
-			patchHandler(w, r, list, id, todoFile)
+		case http.MethodGet, http.MethodHead:
+			getAllHandler(w, r, list, name)
+		case http.MethodPost:
+			addHandler(w, r, list, name, save)
 		default:
-			message := "Message not supported"
+			message := "Method not supported"
 			replyError(w, r, http.StatusMethodNotAllowed, message)
 		}
+		return
+	}
+
+	id, err := validateID(path, list, name)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			replyError(w, r, http.StatusNotFound, err.Error())
+			return
+		}
+		replyError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+		getOneHandler(w, r, list, id)
+	case http.MethodDelete:
+		deleteHandler(w, r, list, id, save)
+	case http.MethodPatch:
+		patchHandler(w, r, list, id, save)
+	case http.MethodPut:
+		putHandler(w, r, list, id, name, save)
+	default:
+		message := "Method not supported"
+		replyError(w, r, http.StatusMethodNotAllowed, message)
 	}
 }
 
-func validateID(path string, list *todo.List) (int, error) {
+// validateID checks the path is the ID of an item in the named list
+func validateID(path string, list *todo.List, name string) (int, error) {
 	id, err := strconv.Atoi(path)
 	if err != nil {
 		return 0, fmt.Errorf("%w: Invalid ID: %s", ErrInvalidData, err)
@@ -82,16 +156,19 @@ func validateID(path string, list *todo.List) (int, error) {
 	if id < 1 {
 		return 0, fmt.Errorf("%w: Invalid ID: Less than one", ErrInvalidData)
 	}
-	if id > len(*list) {
+	named := list.Named(name)
+	if _, err := named.IndexOf(id); err != nil {
 		return 0, fmt.Errorf("%w: ID %d not found", ErrNotFound, id)
 	}
 	return id, nil
 }
 
 func addHandler(w http.ResponseWriter, r *http.Request,
-	list *todo.List, todoFile string) {
+	list *todo.List, name string, save func() error) {
 	item := struct {
-		Task string `json:"task"`
+		Task      string `json:"task"`
+		Parent    int    `json:"parent"`
+		BlockedBy []int  `json:"blockedBy"`
 	}{}
 
 	if err := json.NewDecoder(r.Body).Decode(&item); err != nil {
@@ -100,37 +177,191 @@ func addHandler(w http.ResponseWriter, r *http.Request,
 		return
 	}
 
-	list.Add(item.Task)
-	if err := list.Save(todoFile); err != nil {
+	id := list.Add(item.Task)
+	if err := list.MoveTo(id, name); err != nil {
+		replyError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := list.SetParent(id, item.Parent); err != nil {
+		replyError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := list.Block(id, item.BlockedBy...); err != nil {
+		replyError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := save(); err != nil {
 		replyError(w, r, http.StatusInternalServerError, err.Error())
 		return
 	}
+	w.Header().Set("Location", itemPath(name, id))
 	replyTextContent(w, r, http.StatusCreated, "")
 }
 
-// complete a specific item
+// itemPath returns the path of the item with the given ID in the named list
+func itemPath(name string, id int) string {
+	if name == todo.DefaultList {
+		return fmt.Sprintf("/todo/%d", id)
+	}
+	return fmt.Sprintf("/lists/%s/todo/%d", url.PathEscape(name), id)
+}
+
+// itemChanges holds the fields of an item to be changed,
+// nil fields are left as they are
+type itemChanges struct {
+	Task      *string `json:"task"`
+	Done      *bool   `json:"done"`
+	Position  *int    `json:"position"`
+	Parent    *int    `json:"parent"`
+	BlockedBy *[]int  `json:"blockedBy"`
+	List      *string `json:"list"`
+}
+
+// update a specific item: the complete and reopen query params
+// change its status, otherwise the JSON body gives the fields to change
 func patchHandler(w http.ResponseWriter, r *http.Request,
-	list *todo.List, id int, todoFile string) {
+	list *todo.List, id int, save func() error) {
+
+	changes := itemChanges{}
 
 	q := r.URL.Query() // look for query parameters
-	if _, ok := q["complete"]; !ok {
-		message := "Missing query param 'complete'"
+	_, complete := q["complete"]
+	_, reopen := q["reopen"]
+	switch {
+	case complete || reopen:
+		done := complete
+		changes.Done = &done
+	default:
+		if err := json.NewDecoder(r.Body).Decode(&changes); err != nil {
+			message := fmt.Sprintf(
+				"Missing query param 'complete' or 'reopen', or invalid JSON: %s", err)
+			replyError(w, r, http.StatusBadRequest, message)
+			return
+		}
+	}
+
+	updateItem(w, r, list, id, save, changes)
+}
+
+// replace a specific item of the named list: the JSON body must
+// give the task, and the other missing fields are reset. The item
+// isn't completed, has no parent nor blockers and stays in the named
+// list. Only a missing position keeps the item where it is
+func putHandler(w http.ResponseWriter, r *http.Request,
+	list *todo.List, id int, name string, save func() error) {
+
+	changes := itemChanges{}
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&changes); err != nil {
+		message := fmt.Sprintf("Invalid JSON: %s", err)
 		replyError(w, r, http.StatusBadRequest, message)
 		return
 	}
+	if changes.Task == nil {
+		replyError(w, r, http.StatusBadRequest, "Missing field 'task'")
+		return
+	}
+	if changes.Done == nil {
+		done := false
+		changes.Done = &done
+	}
+	if changes.Parent == nil {
+		parent := 0
+		changes.Parent = &parent
+	}
+	if changes.BlockedBy == nil {
+		changes.BlockedBy = &[]int{}
+	}
+	if changes.List == nil {
+		changes.List = &name
+	}
+
+	updateItem(w, r, list, id, save, changes)
+}
 
-	list.Complete(id)
-	if err := list.Save(todoFile); err != nil {
+func updateItem(w http.ResponseWriter, r *http.Request,
+	list *todo.List, id int, save func() error, changes itemChanges) {
+	if err := applyChanges(list, id, changes); err != nil {
+		// completing an item with open blockers conflicts
+		// with the current state of the list
+		if errors.Is(err, todo.ErrBlocked) {
+			replyError(w, r, http.StatusConflict, err.Error())
+			return
+		}
+		replyError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := save(); err != nil {
 		replyError(w, r, http.StatusInternalServerError, err.Error())
 		return
 	}
 	replyTextContent(w, r, http.StatusNoContent, "")
 }
 
+func applyChanges(list *todo.List, id int, changes itemChanges) error {
+	i, err := list.IndexOf(id)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrNotFound, err)
+	}
+
+	if changes.Task != nil {
+		if err := list.Edit(id, *changes.Task); err != nil {
+			return fmt.Errorf("%w: %s", ErrInvalidData, err)
+		}
+	}
+
+	if changes.List != nil {
+		if err := list.MoveTo(id, *changes.List); err != nil {
+			return fmt.Errorf("%w: %s", ErrInvalidData, err)
+		}
+	}
+
+	if changes.Parent != nil {
+		if err := list.SetParent(id, *changes.Parent); err != nil {
+			return fmt.Errorf("%w: %s", ErrInvalidData, err)
+		}
+	}
+
+	// the blockers given replace the current ones, and are
+	// changed before the status so they're taken into account
+	if changes.BlockedBy != nil {
+		if err := list.Unblock(id, list.Items[i].BlockedBy...); err != nil {
+			return err
+		}
+		if err := list.Block(id, *changes.BlockedBy...); err != nil {
+			return fmt.Errorf("%w: %s", ErrInvalidData, err)
+		}
+	}
+
+	// only change the status when it's different, to keep
+	// the original completion time
+	if changes.Done != nil && *changes.Done != list.Items[i].Done {
+		if *changes.Done {
+			err = list.Complete(id)
+		} else {
+			err = list.Reopen(id)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if changes.Position != nil {
+		if err := list.Move(id, *changes.Position); err != nil {
+			return fmt.Errorf("%w: %s", ErrInvalidData, err)
+		}
+	}
+	return nil
+}
+
 func deleteHandler(w http.ResponseWriter, r *http.Request,
-	list *todo.List, id int, todoFile string) {
-	list.Delete(id)
-	if err := list.Save(todoFile); err != nil {
+	list *todo.List, id int, save func() error) {
+	if err := list.Delete(id); err != nil {
+		replyError(w, r, http.StatusNotFound, err.Error())
+		return
+	}
+	if err := save(); err != nil {
 		replyError(w, r, http.StatusInternalServerError, err.Error())
 		return
 	}
@@ -139,15 +370,119 @@ func deleteHandler(w http.ResponseWriter, r *http.Request,
 
 func getOneHandler(w http.ResponseWriter, r *http.Request,
 	list *todo.List, id int) {
+	i, err := list.IndexOf(id)
+	if err != nil {
+		replyError(w, r, http.StatusNotFound, err.Error())
+		return
+	}
 	resp := &todoResponse{
-		Results: (*list)[id-1 : id],
+		Results:      todo.List{Items: list.Items[i : i+1]},
+		TotalResults: 1,
 	}
 	replyJSONContent(w, r, http.StatusOK, resp)
 }
 
-func getAllHandler(w http.ResponseWriter, r *http.Request, list *todo.List) {
+// get the items of the named list, only the ones matching the
+// q query param when given. The match param sets the search mode,
+// done filters them by status and sort orders them by keys such as
+// "due,-created". Pages of them are given by limit and offset, with
+// the total number of matching items
+func getAllHandler(w http.ResponseWriter, r *http.Request,
+	list *todo.List, name string) {
+	named := list.Named(name)
+
+	q := r.URL.Query()
+	if _, ok := q["q"]; ok {
+		found, err := named.Search(q.Get("q"), q.Get("match"))
+		if err != nil {
+			replyError(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+		named = found
+	}
+
+	var terms []string
+	if done := q.Get("done"); done != "" {
+		if done != "true" && done != "false" {
+			message := fmt.Sprintf("Invalid done param %q: must be true or false", done)
+			replyError(w, r, http.StatusBadRequest, message)
+			return
+		}
+		terms = append(terms, "done:"+done)
+	}
+	if sortBy := q.Get("sort"); sortBy != "" {
+		terms = append(terms, "sort:"+sortBy)
+	}
+	if len(terms) > 0 {
+		query, err := todo.NewQuery(terms...)
+		if err != nil {
+			replyError(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+		named = named.Filter(query)
+	}
+
+	offset, limit, err := pageParams(q)
+	if err != nil {
+		replyError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
 	resp := &todoResponse{
-		Results: *list,
+		Results:      page(named, offset, limit),
+		TotalResults: len(named.Items),
+		Offset:       offset,
+		Limit:        limit,
+	}
+	replyJSONContent(w, r, http.StatusOK, resp)
+}
+
+// largest page of items, to keep the responses small
+const maxLimit = 1000
+
+// pageParams returns the offset and limit query params,
+// a missing limit meaning all the items
+func pageParams(q url.Values) (offset, limit int, err error) {
+	if v := q.Get("offset"); v != "" {
+		offset, err = strconv.Atoi(v)
+		if err != nil || offset < 0 {
+			return 0, 0, fmt.Errorf("%w: Invalid offset %q: must be a number from 0", ErrInvalidData, v)
+		}
+	}
+	if v := q.Get("limit"); v != "" {
+		limit, err = strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > maxLimit {
+			return 0, 0, fmt.Errorf("%w: Invalid limit %q: must be a number from 1 to %d",
+				ErrInvalidData, v, maxLimit)
+		}
+	}
+	return offset, limit, nil
+}
+
+// page returns the limit items from offset, all of them
+// from offset when limit is 0
+func page(list todo.List, offset, limit int) todo.List {
+	if offset >= len(list.Items) {
+		return todo.List{}
+	}
+	items := list.Items[offset:]
+	if limit > 0 && limit < len(items) {
+		items = items[:limit]
+	}
+	return todo.List{Items: items}
+}
+
+func getListsHandler(w http.ResponseWriter, r *http.Request, store *listStore) {
+	var lists []todo.ListSummary
+	err := store.read(func(list *todo.List) {
+		lists = list.Lists()
+	})
+	if err != nil {
+		replyError(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+	resp := &listsResponse{
+		Results: lists,
 	}
 	replyJSONContent(w, r, http.StatusOK, resp)
 }
@@ -5,18 +5,72 @@ import (
 	"fmt"
 	"net/http"
 	"os"
+	"strings"
 	"time"
+	"todo"
 )
 
 func main() {
 	host := flag.String("h", "localhost", "Server host")
 	port := flag.Int("p", 8080, "Server port")
-	todoFile := flag.String("f", "todoServer.json", "todo JSON file")
+	todoFile := flag.String("f", "todoServer.json",
+		"todo storage: a JSON file, sqlite:FILE or memory:")
+	archiveDays := flag.Int("archive-days", 0,
+		"Archive every hour the items completed more than N days ago, 0 to never archive")
+	archiveFile := flag.String("archive", "todoServer.archive.json",
+		"archive storage, in the same format as -f")
+	purge := flag.Bool("purge", false, "With -archive-days, delete the items instead of archiving them")
+	tokenFile := flag.String("tokens", "",
+		"token file with \"TOKEN USER [read-only]\" lines, to give every user their own list")
 	flag.Parse()
 
+	// encrypted JSON files are read and saved with the passphrase
+	// from the environment, like with the todo CLI
+	passphrase := os.Getenv("TODO_PASSPHRASE")
+	specs := []string{*todoFile}
+	if *archiveDays > 0 && !*purge {
+		specs = append(specs, *archiveFile)
+	}
+	if err := checkPassphrase(passphrase, specs...); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	key := todo.NewKey(passphrase)
+
+	var handler http.Handler
+	if *tokenFile == "" {
+		store, stop, err := openStore(*todoFile, *archiveFile, *archiveDays, *purge, key)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		defer stop()
+		handler = newMux(store)
+	} else {
+		// every user gets their own list, with their name
+		// added to the file names
+		t, err := loadTokens(*tokenFile)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		handlers := map[string]http.Handler{}
+		for _, name := range t.users() {
+			store, stop, err := openStore(userSpec(*todoFile, name),
+				userSpec(*archiveFile, name), *archiveDays, *purge, key)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			defer stop()
+			handlers[name] = newMux(store)
+		}
+		handler = newAuthMux(t, handlers)
+	}
+
 	s := &http.Server{
 		Addr:         fmt.Sprintf("%s:%d", *host, *port),
-		Handler:      newMux(*todoFile),
+		Handler:      handler,
 		ReadTimeout:  10 * time.Second,
 		WriteTimeout: 10 * time.Second,
 	}
@@ -26,3 +80,67 @@ func main() {
 		os.Exit(1)
 	}
 }
+
+// checkPassphrase checks the storages of specs can be encrypted
+// with passphrase, as only JSON files can
+func checkPassphrase(passphrase string, specs ...string) error {
+	if passphrase == "" {
+		return nil
+	}
+	for _, spec := range specs {
+		if storageKind(spec) != todo.StorageJSON {
+			return fmt.Errorf("encryption needs the %s storage", todo.StorageJSON)
+		}
+	}
+	return nil
+}
+
+// storageKind returns the kind of storage of a spec
+// as accepted by todo.OpenStorage
+func storageKind(spec string) string {
+	if kv := strings.SplitN(spec, ":", 2); len(kv) == 2 {
+		switch kind := strings.ToLower(kv[0]); kind {
+		case todo.StorageSQLite, "sqlite3":
+			return todo.StorageSQLite
+		case todo.StorageJSON, todo.StorageMemory:
+			return kind
+		}
+	}
+	return todo.StorageJSON
+}
+
+// openStore opens the storage of a list encrypted with key, nil if
+// it isn't, and loads it, archiving it when archiveDays is set,
+// and returns a function closing both
+func openStore(spec, archiveSpec string, archiveDays int,
+	purge bool, key *todo.Key) (*listStore, func(), error) {
+	storage, err := todo.OpenStorage(spec, todo.WithKey(key))
+	if err != nil {
+		return nil, nil, err
+	}
+	store, err := newListStore(storage)
+	if err != nil {
+		storage.Close()
+		return nil, nil, err
+	}
+	if archiveDays <= 0 {
+		return store, func() { storage.Close() }, nil
+	}
+
+	var archive todo.Storage
+	if !purge {
+		archive, err = todo.OpenStorage(archiveSpec, todo.WithKey(key))
+		if err != nil {
+			storage.Close()
+			return nil, nil, err
+		}
+	}
+	stopArchive := scheduleArchive(store, archive, archiveDays, time.Hour)
+	return store, func() {
+		stopArchive()
+		if archive != nil {
+			archive.Close()
+		}
+		storage.Close()
+	}, nil
+}
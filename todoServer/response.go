@@ -1,13 +1,21 @@
 package main
 
 import (
+	"bytes"
 	"encoding/json"
+	"net/http"
 	"time"
 	"todo"
 )
 
+// todoResponse is a page of items, with the number of items
+// matching the request in all the pages. Limit is 0 when all
+// the items from offset are given
 type todoResponse struct {
-	Results todo.List `json:"results"`
+	Results      todo.List
+	TotalResults int
+	Offset       int
+	Limit        int
 }
 
 func (t *todoResponse) MarshalJSON() ([]byte, error) {
@@ -15,10 +23,86 @@ func (t *todoResponse) MarshalJSON() ([]byte, error) {
 		Results      todo.List `json:"results"`
 		Date         int64     `json:"date"`
 		TotalResults int       `json:"totalResults"`
+		Offset       int       `json:"offset"`
+		Limit        int       `json:"limit,omitempty"`
 	}{
 		Results:      t.Results,
 		Date:         time.Now().Unix(),
-		TotalResults: len(t.Results),
+		TotalResults: t.TotalResults,
+		Offset:       t.Offset,
+		Limit:        t.Limit,
 	}
 	return json.Marshal(resp)
 }
+
+type listsResponse struct {
+	Results []todo.ListSummary `json:"results"`
+}
+
+// errorResponse is the envelope of every error reply
+type errorResponse struct {
+	Error apiError `json:"error"`
+}
+
+// apiError describes a failed request. Code is a stable name for the
+// kind of failure, Message its short description and Details what
+// exactly failed. The request ID is also in the X-Request-ID header
+// and the server log, to find the request there
+type apiError struct {
+	Code      string `json:"code"`
+	Message   string `json:"message"`
+	Details   string `json:"details,omitempty"`
+	RequestID string `json:"requestId"`
+}
+
+// error codes, by HTTP status
+var errorCodes = map[int]string{
+	http.StatusBadRequest:          "invalid_data",
+	http.StatusUnauthorized:        "unauthorized",
+	http.StatusForbidden:           "forbidden",
+	http.StatusNotFound:            "not_found",
+	http.StatusMethodNotAllowed:    "method_not_allowed",
+	http.StatusConflict:            "conflict",
+	http.StatusInternalServerError: "internal_error",
+}
+
+func errorCode(status int) string {
+	if code, ok := errorCodes[status]; ok {
+		return code
+	}
+	return "error"
+}
+
+// responseBuffer keeps a response in memory, to write it later
+type responseBuffer struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+// newResponseBuffer returns a buffer for the response to w,
+// starting with the headers already set on w
+func newResponseBuffer(w http.ResponseWriter) *responseBuffer {
+	return &responseBuffer{header: w.Header().Clone(), status: http.StatusOK}
+}
+
+func (b *responseBuffer) Header() http.Header {
+	return b.header
+}
+
+func (b *responseBuffer) Write(p []byte) (int, error) {
+	return b.body.Write(p)
+}
+
+func (b *responseBuffer) WriteHeader(status int) {
+	b.status = status
+}
+
+// writeTo writes the buffered response to w
+func (b *responseBuffer) writeTo(w http.ResponseWriter) {
+	for k, v := range b.header {
+		w.Header()[k] = v
+	}
+	w.WriteHeader(b.status)
+	w.Write(b.body.Bytes())
+}
@@ -1,24 +1,30 @@
 package main
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
 	"log"
 	"net/http"
-	"sync"
+	"regexp"
+	"time"
 )
 
-func newMux(todoFile string) http.Handler {
+func newMux(store *listStore) http.Handler {
 	m := http.NewServeMux()
 
 	m.HandleFunc("/", rootHandler)
+	m.HandleFunc("/openapi.json", openAPIHandler)
 
-	mu := &sync.Mutex{}
-
-	t := todoRouter(todoFile, mu)
+	t := todoRouter(store)
 	m.Handle("/todo", http.StripPrefix("/todo", t))
 	m.Handle("/todo/", http.StripPrefix("/todo/", t))
 
-	return m
+	l := listsRouter(store)
+	m.Handle("/lists", http.StripPrefix("/lists", l))
+	m.Handle("/lists/", http.StripPrefix("/lists/", l))
+
+	return withRequestID(m)
 }
 
 func replyTextContent(writer http.ResponseWriter, request *http.Request, status int, content string) {
@@ -28,7 +34,7 @@ func replyTextContent(writer http.ResponseWriter, request *http.Request, status
 }
 
 func replyJSONContent(w http.ResponseWriter, r *http.Request,
-	status int, resp *todoResponse) {
+	status int, resp interface{}) {
 	body, err := json.Marshal(resp)
 	if err != nil {
 		replyError(w, r, http.StatusInternalServerError, err.Error())
@@ -40,8 +46,65 @@ func replyJSONContent(w http.ResponseWriter, r *http.Request,
 	w.Write(body)
 }
 
+// replyError replies with the JSON error envelope. The details of
+// internal errors are only logged, not to leak them to clients
 func replyError(w http.ResponseWriter, r *http.Request,
 	status int, message string) {
-	log.Printf("%s %s: Error: %d %s", r.URL, r.Method, status, message)
-	http.Error(w, http.StatusText(status), status)
+	id := requestID(w, r)
+	log.Printf("%s %s %s: Error: %d %s", id, r.URL, r.Method, status, message)
+
+	resp := errorResponse{
+		Error: apiError{
+			Code:      errorCode(status),
+			Message:   http.StatusText(status),
+			Details:   message,
+			RequestID: id,
+		},
+	}
+	if status >= http.StatusInternalServerError {
+		resp.Error.Details = ""
+	}
+	body, err := json.Marshal(resp)
+	if err != nil {
+		http.Error(w, http.StatusText(status), status)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	w.Write(body)
+}
+
+const requestIDHeader = "X-Request-ID"
+
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// requestID returns the ID of the request, set in the X-Request-ID
+// header of the response. It's the one given by the client in the
+// same header when valid, or a new random one
+func requestID(w http.ResponseWriter, r *http.Request) string {
+	if id := w.Header().Get(requestIDHeader); id != "" {
+		return id
+	}
+
+	id := r.Header.Get(requestIDHeader)
+	if !validRequestID.MatchString(id) {
+		b := make([]byte, 8)
+		if _, err := rand.Read(b); err != nil {
+			// not unique, but still lets the log be searched
+			b = []byte(time.Now().Format("150405.000"))
+		}
+		id = hex.EncodeToString(b)
+	}
+	w.Header().Set(requestIDHeader, id)
+	return id
+}
+
+// withRequestID gives every request an ID before serving it with h
+func withRequestID(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID(w, r)
+		h.ServeHTTP(w, r)
+	})
 }
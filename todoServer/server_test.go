@@ -3,14 +3,17 @@ package main
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"log"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 	"todo"
 )
 
@@ -22,7 +25,12 @@ func setupAPI(t *testing.T) (string, func()) {
 		t.Fatal(err)
 	}
 
-	ts := httptest.NewServer(newMux(tempTodoFile.Name()))
+	store, err := todo.NewStorage(todo.StorageJSON, tempTodoFile.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ts := httptest.NewServer(newMux(loadStore(t, store)))
 
 	// add a couple of items for testing
 	for i := 1; i < 3; i++ {
@@ -48,10 +56,23 @@ func setupAPI(t *testing.T) (string, func()) {
 
 	return ts.URL, func() {
 		ts.Close()
+		store.Close()
 		os.Remove(tempTodoFile.Name())
+		os.Remove(tempTodoFile.Name() + ".lock")
 	}
 }
 
+// loadStore loads the list of storage in a store
+func loadStore(t *testing.T, storage todo.Storage) *listStore {
+	t.Helper()
+
+	store, err := newListStore(storage)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return store
+}
+
 func TestGet(t *testing.T) {
 	testCases := []struct {
 		name       string
@@ -81,9 +102,10 @@ func TestGet(t *testing.T) {
 			expContent: "Task number 1.",
 		},
 		{
-			name:    "NotFound",
-			path:    "/todo/500",
-			expCode: http.StatusNotFound,
+			name:       "NotFound",
+			path:       "/todo/500",
+			expCode:    http.StatusNotFound,
+			expContent: "ID 500 not found",
 		},
 	}
 
@@ -99,6 +121,7 @@ func TestGet(t *testing.T) {
 					Results      todo.List `json:"results"`
 					Date         int64     `json:"date"`
 					TotalResults int       `json:"totalResults"`
+					Error        apiError  `json:"error"`
 				}
 			)
 			r, err := http.Get(url + tc.path)
@@ -118,13 +141,19 @@ func TestGet(t *testing.T) {
 				if err = json.NewDecoder(r.Body).Decode(&resp); err != nil {
 					t.Error(err)
 				}
+				if r.StatusCode != http.StatusOK {
+					if !strings.Contains(resp.Error.Details, tc.expContent) {
+						t.Errorf("Expected error %q, got %+v.", tc.expContent, resp.Error)
+					}
+					return
+				}
 				if resp.TotalResults != tc.expItems {
 					t.Errorf("Expected %d items, got %d.",
 						tc.expItems, resp.TotalResults)
 				}
-				if resp.Results[0].Task != tc.expContent {
+				if resp.Results.Items[0].Task != tc.expContent {
 					t.Errorf("Expected %q, got %q.",
-						tc.expContent, resp.Results[0].Task)
+						tc.expContent, resp.Results.Items[0].Task)
 				}
 			case strings.Contains(r.Header.Get("Content-Type"), "text/plain"):
 				if body, err = ioutil.ReadAll(r.Body); err != nil {
@@ -166,6 +195,9 @@ func TestAdd(t *testing.T) {
 				http.StatusText(http.StatusCreated),
 				http.StatusText(r.StatusCode))
 		}
+		if loc := r.Header.Get("Location"); loc != "/todo/3" {
+			t.Errorf("Expected location %q, got %q.", "/todo/3", loc)
+		}
 	})
 
 	t.Run("CheckAdd", func(t *testing.T) {
@@ -183,9 +215,9 @@ func TestAdd(t *testing.T) {
 			t.Fatal(err)
 		}
 		r.Body.Close()
-		if resp.Results[0].Task != taskName {
+		if resp.Results.Items[0].Task != taskName {
 			t.Errorf("Expected %q, got %q.", taskName,
-				resp.Results[0].Task)
+				resp.Results.Items[0].Task)
 		}
 	})
 }
@@ -228,12 +260,40 @@ func TestDelete(t *testing.T) {
 		}
 		r.Body.Close()
 
-		if len(resp.Results) != 1 {
-			t.Errorf("Excepted 1 item, got %d.", len(resp.Results))
+		if len(resp.Results.Items) != 1 {
+			t.Errorf("Excepted 1 item, got %d.", len(resp.Results.Items))
 		}
 		expTask := "Task number 2."
-		if resp.Results[0].Task != expTask {
-			t.Errorf("Expected %q, got %q.", expTask, resp.Results[0].Task)
+		if resp.Results.Items[0].Task != expTask {
+			t.Errorf("Expected %q, got %q.", expTask, resp.Results.Items[0].Task)
+		}
+	})
+
+	t.Run("CheckStableID", func(t *testing.T) {
+		r, err := http.Get(url + "/todo/1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		r.Body.Close()
+		if r.StatusCode != http.StatusNotFound {
+			t.Fatalf("Expected %q, got %q.",
+				http.StatusText(http.StatusNotFound),
+				http.StatusText(r.StatusCode))
+		}
+
+		r, err = http.Get(url + "/todo/2")
+		if err != nil {
+			t.Fatal(err)
+		}
+		var resp todoResponse
+		if err := json.NewDecoder(r.Body).Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+		r.Body.Close()
+
+		expTask := "Task number 2."
+		if resp.Results.Items[0].Task != expTask {
+			t.Errorf("Expected %q, got %q.", expTask, resp.Results.Items[0].Task)
 		}
 	})
 }
@@ -275,18 +335,818 @@ func TestComplete(t *testing.T) {
 			t.Fatal(err)
 		}
 		r.Body.Close()
-		if len(resp.Results) != 2 {
-			t.Errorf("Expected 2 items, got %d.", len(resp.Results))
+		if len(resp.Results.Items) != 2 {
+			t.Errorf("Expected 2 items, got %d.", len(resp.Results.Items))
 		}
-		if !resp.Results[0].Done {
+		if !resp.Results.Items[0].Done {
 			t.Errorf("Expected Item 1 to be completed")
 		}
-		if resp.Results[1].Done {
+		if resp.Results.Items[1].Done {
 			t.Errorf("Expected Item 2 not to be completed")
 		}
 	})
 }
 
+func TestUpdate(t *testing.T) {
+	testCases := []struct {
+		name     string
+		method   string
+		path     string
+		body     string
+		expCode  int
+		expTasks []string
+		expDone  []bool
+	}{
+		{
+			name:     "PatchReopen",
+			method:   http.MethodPatch,
+			path:     "/todo/2?reopen",
+			expCode:  http.StatusNoContent,
+			expTasks: []string{"Task number 1.", "Task number 2."},
+			expDone:  []bool{false, false},
+		},
+		{
+			name:     "PatchTask",
+			method:   http.MethodPatch,
+			path:     "/todo/1",
+			body:     `{"task":"Task number one."}`,
+			expCode:  http.StatusNoContent,
+			expTasks: []string{"Task number one.", "Task number 2."},
+			expDone:  []bool{false, true},
+		},
+		{
+			name:     "PatchPosition",
+			method:   http.MethodPatch,
+			path:     "/todo/2",
+			body:     `{"position":1}`,
+			expCode:  http.StatusNoContent,
+			expTasks: []string{"Task number 2.", "Task number 1."},
+			expDone:  []bool{true, false},
+		},
+		{
+			name:     "Put",
+			method:   http.MethodPut,
+			path:     "/todo/2",
+			body:     `{"task":"Task number two."}`,
+			expCode:  http.StatusNoContent,
+			expTasks: []string{"Task number 1.", "Task number two."},
+			expDone:  []bool{false, false},
+		},
+		{
+			name:    "PutMissingTask",
+			method:  http.MethodPut,
+			path:    "/todo/2",
+			body:    `{"done":true}`,
+			expCode: http.StatusBadRequest,
+		},
+		{
+			name:    "PutUnknownField",
+			method:  http.MethodPut,
+			path:    "/todo/2",
+			body:    `{"task":"Task number two.","priority":"A"}`,
+			expCode: http.StatusBadRequest,
+		},
+		{
+			name:    "PatchInvalidPosition",
+			method:  http.MethodPatch,
+			path:    "/todo/2",
+			body:    `{"position":3}`,
+			expCode: http.StatusBadRequest,
+		},
+		{
+			name:    "PatchNoChanges",
+			method:  http.MethodPatch,
+			path:    "/todo/2",
+			expCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			url, cleanup := setupAPI(t)
+			defer cleanup()
+
+			// complete the second item so reopening can be checked
+			req, err := http.NewRequest(http.MethodPatch, url+"/todo/2?complete", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := http.DefaultClient.Do(req); err != nil {
+				t.Fatal(err)
+			}
+
+			req, err = http.NewRequest(tc.method, url+tc.path, strings.NewReader(tc.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			r, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			r.Body.Close()
+			if r.StatusCode != tc.expCode {
+				t.Fatalf("Expected %q, got %q.",
+					http.StatusText(tc.expCode),
+					http.StatusText(r.StatusCode))
+			}
+			if tc.expTasks == nil {
+				return
+			}
+
+			r, err = http.Get(url + "/todo")
+			if err != nil {
+				t.Fatal(err)
+			}
+			var resp todoResponse
+			if err := json.NewDecoder(r.Body).Decode(&resp); err != nil {
+				t.Fatal(err)
+			}
+			r.Body.Close()
+
+			for i, v := range resp.Results.Items {
+				if v.Task != tc.expTasks[i] {
+					t.Errorf("Expected %q, got %q.", tc.expTasks[i], v.Task)
+				}
+				if v.Done != tc.expDone[i] {
+					t.Errorf("Expected item %d done %t, got %t.", i+1, tc.expDone[i], v.Done)
+				}
+			}
+		})
+	}
+}
+
+func TestSubtasksAndBlockers(t *testing.T) {
+	url, cleanup := setupAPI(t)
+	defer cleanup()
+
+	send := func(method, path, body string) int {
+		t.Helper()
+		req, err := http.NewRequest(method, url+path, strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		r, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r.Body.Close()
+		return r.StatusCode
+	}
+
+	steps := []struct {
+		method  string
+		path    string
+		body    string
+		expCode int
+	}{
+		{http.MethodPost, "/todo", `{"task":"Subtask","parent":1,"blockedBy":[2]}`, http.StatusCreated},
+		{http.MethodPost, "/todo", `{"task":"Orphan","parent":10}`, http.StatusBadRequest},
+		{http.MethodPatch, "/todo/3?complete", "", http.StatusConflict},
+		{http.MethodPatch, "/todo/2", `{"blockedBy":[3]}`, http.StatusBadRequest},
+		{http.MethodPatch, "/todo/1", `{"parent":3}`, http.StatusBadRequest},
+		{http.MethodPatch, "/todo/3", `{"blockedBy":[],"done":true}`, http.StatusNoContent},
+	}
+	for _, s := range steps {
+		if code := send(s.method, s.path, s.body); code != s.expCode {
+			t.Fatalf("%s %s: expected %q, got %q.", s.method, s.path,
+				http.StatusText(s.expCode), http.StatusText(code))
+		}
+	}
+
+	type subtask struct {
+		Done      bool
+		Parent    int
+		BlockedBy []int
+		ListName  string
+	}
+	get := func() subtask {
+		t.Helper()
+		r, err := http.Get(url + "/todo/3")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer r.Body.Close()
+
+		var resp struct {
+			Results []subtask `json:"results"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+		return resp.Results[0]
+	}
+
+	v := get()
+	if !v.Done || v.Parent != 1 || len(v.BlockedBy) != 0 {
+		t.Errorf("Unexpected item %+v", v)
+	}
+
+	// replacing the item resets the fields missing from the body
+	if code := send(http.MethodPut, "/todo/3", `{"task":"Subtask","blockedBy":[2]}`); code != http.StatusNoContent {
+		t.Fatalf("Expected %q, got %q.",
+			http.StatusText(http.StatusNoContent), http.StatusText(code))
+	}
+	v = get()
+	if v.Done || v.Parent != 0 || len(v.BlockedBy) != 1 || v.BlockedBy[0] != 2 ||
+		v.ListName != "" {
+		t.Errorf("Unexpected replaced item %+v", v)
+	}
+}
+
+func TestNamedLists(t *testing.T) {
+	url, cleanup := setupAPI(t)
+	defer cleanup()
+
+	steps := []struct {
+		method  string
+		path    string
+		body    string
+		expCode int
+	}{
+		{http.MethodPost, "/lists/work/todo", `{"task":"Write report"}`, http.StatusCreated},
+		{http.MethodPatch, "/todo/2", `{"list":"work"}`, http.StatusNoContent},
+		{http.MethodPatch, "/lists/work/todo/3?complete", "", http.StatusNoContent},
+		{http.MethodGet, "/lists/work/todo/1", "", http.StatusNotFound},
+		{http.MethodGet, "/todo/3", "", http.StatusNotFound},
+		{http.MethodGet, "/lists/work/items", "", http.StatusNotFound},
+		{http.MethodPatch, "/lists/work/todo/3", `{"list":"my list"}`, http.StatusBadRequest},
+		// subtasks and blockers are in the list of their item
+		{http.MethodPost, "/lists/work/todo", `{"task":"Subtask","parent":1}`, http.StatusBadRequest},
+		{http.MethodPost, "/todo", `{"task":"Subtask","blockedBy":[2]}`, http.StatusBadRequest},
+		{http.MethodPatch, "/todo/1", `{"parent":2}`, http.StatusBadRequest},
+		{http.MethodPatch, "/lists/work/todo/3", `{"blockedBy":[1]}`, http.StatusBadRequest},
+		{http.MethodPatch, "/lists/work/todo/3", `{"parent":2}`, http.StatusNoContent},
+	}
+	for _, s := range steps {
+		req, err := http.NewRequest(s.method, url+s.path, strings.NewReader(s.body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		r, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r.Body.Close()
+		if r.StatusCode != s.expCode {
+			t.Fatalf("%s %s: expected %q, got %q.", s.method, s.path,
+				http.StatusText(s.expCode), http.StatusText(r.StatusCode))
+		}
+	}
+
+	t.Run("GetNamed", func(t *testing.T) {
+		r, err := http.Get(url + "/lists/work/todo")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer r.Body.Close()
+
+		var resp todoResponse
+		if err := json.NewDecoder(r.Body).Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+		exp := []int{2, 3}
+		if len(resp.Results.Items) != len(exp) {
+			t.Fatalf("Expected %d items, got %d.", len(exp), len(resp.Results.Items))
+		}
+		for i, v := range resp.Results.Items {
+			if v.ID != exp[i] {
+				t.Errorf("Expected item %d, got %d.", exp[i], v.ID)
+			}
+		}
+	})
+
+	t.Run("GetLists", func(t *testing.T) {
+		r, err := http.Get(url + "/lists")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer r.Body.Close()
+
+		var resp listsResponse
+		if err := json.NewDecoder(r.Body).Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+		exp := []todo.ListSummary{
+			{Name: todo.DefaultList, Items: 1},
+			{Name: "work", Items: 2, Done: 1},
+		}
+		if len(resp.Results) != len(exp) {
+			t.Fatalf("Expected %v, got %v.", exp, resp.Results)
+		}
+		for i := range exp {
+			if resp.Results[i] != exp[i] {
+				t.Errorf("Expected %v, got %v.", exp[i], resp.Results[i])
+			}
+		}
+	})
+}
+
+func TestSearch(t *testing.T) {
+	testCases := []struct {
+		name    string
+		query   string
+		expCode int
+		expIDs  []int
+	}{
+		{name: "Substring", query: "?q=NUMBER", expCode: http.StatusOK, expIDs: []int{1, 2}},
+		{name: "Word", query: "?q=number+2&match=word", expCode: http.StatusOK, expIDs: []int{2}},
+		{name: "Regexp", query: "?q=%5Etask.*1&match=regexp", expCode: http.StatusOK, expIDs: []int{1}},
+		{name: "NoMatch", query: "?q=milk", expCode: http.StatusOK, expIDs: []int{}},
+		{name: "BlankPattern", query: "?q=", expCode: http.StatusBadRequest},
+		{name: "InvalidRegexp", query: "?q=(task&match=regexp", expCode: http.StatusBadRequest},
+	}
+
+	url, cleanup := setupAPI(t)
+	defer cleanup()
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r, err := http.Get(url + "/todo" + tc.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer r.Body.Close()
+
+			if r.StatusCode != tc.expCode {
+				t.Fatalf("Expected %q, got %q.",
+					http.StatusText(tc.expCode),
+					http.StatusText(r.StatusCode))
+			}
+			if tc.expIDs == nil {
+				return
+			}
+
+			var resp todoResponse
+			if err := json.NewDecoder(r.Body).Decode(&resp); err != nil {
+				t.Fatal(err)
+			}
+			if len(resp.Results.Items) != len(tc.expIDs) {
+				t.Fatalf("Expected %d items, got %d.", len(tc.expIDs), len(resp.Results.Items))
+			}
+			for i, v := range resp.Results.Items {
+				if v.ID != tc.expIDs[i] {
+					t.Errorf("Expected item %d, got %d.", tc.expIDs[i], v.ID)
+				}
+			}
+		})
+	}
+}
+
+func TestGetPages(t *testing.T) {
+	url, cleanup := setupAPI(t)
+	defer cleanup()
+
+	for _, task := range []string{"Buy milk.", "Call mom.", "Archive mail."} {
+		body := strings.NewReader(fmt.Sprintf(`{"task":%q}`, task))
+		r, err := http.Post(url+"/todo", "application/json", body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r.Body.Close()
+	}
+	req, err := http.NewRequest(http.MethodPatch, url+"/todo/2?complete", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Body.Close()
+
+	testCases := []struct {
+		name     string
+		query    string
+		expCode  int
+		expIDs   []int
+		expTotal int
+	}{
+		{name: "All", query: "", expCode: http.StatusOK, expIDs: []int{1, 2, 3, 4, 5}, expTotal: 5},
+		{name: "FirstPage", query: "?limit=2", expCode: http.StatusOK, expIDs: []int{1, 2}, expTotal: 5},
+		{name: "LastPage", query: "?limit=2&offset=4", expCode: http.StatusOK, expIDs: []int{5}, expTotal: 5},
+		{name: "PastTheEnd", query: "?limit=2&offset=10", expCode: http.StatusOK, expIDs: []int{}, expTotal: 5},
+		{name: "Open", query: "?done=false", expCode: http.StatusOK, expIDs: []int{1, 3, 4, 5}, expTotal: 4},
+		{name: "Done", query: "?done=true", expCode: http.StatusOK, expIDs: []int{2}, expTotal: 1},
+		{name: "SortTask", query: "?sort=task&limit=3", expCode: http.StatusOK, expIDs: []int{5, 3, 4}, expTotal: 5},
+		{name: "SortDesc", query: "?sort=-id&done=false&offset=1", expCode: http.StatusOK, expIDs: []int{4, 3, 1}, expTotal: 4},
+		{name: "SearchPage", query: "?q=number&limit=1&offset=1", expCode: http.StatusOK, expIDs: []int{2}, expTotal: 2},
+		{name: "InvalidDone", query: "?done=yes", expCode: http.StatusBadRequest},
+		{name: "InvalidSort", query: "?sort=name", expCode: http.StatusBadRequest},
+		{name: "InvalidLimit", query: "?limit=0", expCode: http.StatusBadRequest},
+		{name: "LimitTooLarge", query: "?limit=1001", expCode: http.StatusBadRequest},
+		{name: "InvalidOffset", query: "?offset=-1", expCode: http.StatusBadRequest},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r, err := http.Get(url + "/todo" + tc.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer r.Body.Close()
+
+			if r.StatusCode != tc.expCode {
+				t.Fatalf("Expected %q, got %q.",
+					http.StatusText(tc.expCode),
+					http.StatusText(r.StatusCode))
+			}
+			if tc.expIDs == nil {
+				return
+			}
+
+			var resp todoResponse
+			if err := json.NewDecoder(r.Body).Decode(&resp); err != nil {
+				t.Fatal(err)
+			}
+			if resp.TotalResults != tc.expTotal {
+				t.Errorf("Expected %d total results, got %d.", tc.expTotal, resp.TotalResults)
+			}
+			if len(resp.Results.Items) != len(tc.expIDs) {
+				t.Fatalf("Expected %d items, got %d.", len(tc.expIDs), len(resp.Results.Items))
+			}
+			for i, v := range resp.Results.Items {
+				if v.ID != tc.expIDs[i] {
+					t.Errorf("Expected item %d, got %d.", tc.expIDs[i], v.ID)
+				}
+			}
+		})
+	}
+}
+
+func TestScheduleArchive(t *testing.T) {
+	store, err := todo.NewStorage(todo.StorageMemory, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	archive, err := todo.NewStorage(todo.StorageMemory, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ls := &todo.List{}
+	ls.Add("Task number 1.")
+	ls.Add("Task number 2.")
+	ls.Complete(2)
+	if err := store.Save(ls); err != nil {
+		t.Fatal(err)
+	}
+
+	stop := scheduleArchive(loadStore(t, store), archive, 0, 10*time.Millisecond)
+	time.Sleep(50 * time.Millisecond)
+	stop()
+
+	for _, tc := range []struct {
+		store  todo.Storage
+		expIDs []int
+	}{
+		{store, []int{1}},
+		{archive, []int{2}},
+	} {
+		got := &todo.List{}
+		if err := tc.store.Get(got); err != nil {
+			t.Fatal(err)
+		}
+		if len(got.Items) != len(tc.expIDs) || got.Items[0].ID != tc.expIDs[0] {
+			t.Errorf("Expected items %v, got:\n%s", tc.expIDs, got)
+		}
+	}
+}
+
+// failingStorage fails to save the list
+type failingStorage struct {
+	todo.Storage
+}
+
+var errSave = errors.New("disk full")
+
+func (s failingStorage) Save(list *todo.List) error {
+	return errSave
+}
+
+func TestArchiveItems_SaveFails(t *testing.T) {
+	store, err := todo.NewStorage(todo.StorageMemory, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	archive, err := todo.NewStorage(todo.StorageMemory, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ls := &todo.List{}
+	ls.Add("Task number 1.")
+	ls.Complete(1)
+	if err := store.Save(ls); err != nil {
+		t.Fatal(err)
+	}
+
+	n, err := archiveItems(loadStore(t, failingStorage{store}), archive, time.Now())
+	if !errors.Is(err, errSave) || n != 0 {
+		t.Fatalf("Expected %q and no items, got %v and %d.", errSave, err, n)
+	}
+
+	// the items stay in the list only
+	for _, tc := range []struct {
+		store todo.Storage
+		exp   int
+	}{
+		{store, 1},
+		{archive, 0},
+	} {
+		got := &todo.List{}
+		if err := tc.store.Get(got); err != nil {
+			t.Fatal(err)
+		}
+		if len(got.Items) != tc.exp {
+			t.Errorf("Expected %d items, got:\n%s", tc.exp, got)
+		}
+	}
+}
+
+func TestMemoryStorage(t *testing.T) {
+	store, err := todo.OpenStorage("memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts := httptest.NewServer(newMux(loadStore(t, store)))
+	defer ts.Close()
+
+	body := strings.NewReader(`{"task":"Task in memory."}`)
+	r, err := http.Post(ts.URL+"/todo", "application/json", body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Body.Close()
+	if r.StatusCode != http.StatusCreated {
+		t.Fatalf("Expected %q, got %q.",
+			http.StatusText(http.StatusCreated),
+			http.StatusText(r.StatusCode))
+	}
+
+	r, err = http.Get(ts.URL + "/todo/1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var resp todoResponse
+	if err := json.NewDecoder(r.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	r.Body.Close()
+	if resp.Results.Items[0].Task != "Task in memory." {
+		t.Errorf("Expected %q, got %q.", "Task in memory.", resp.Results.Items[0].Task)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	url, cleanup := setupAPI(t)
+	defer cleanup()
+
+	testCases := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		expCode    int
+		expErrCode string
+		expDetails string
+	}{
+		{"UnknownPath", http.MethodGet, "/unknown", "",
+			http.StatusNotFound, "not_found", ""},
+		{"NotFound", http.MethodDelete, "/todo/500", "",
+			http.StatusNotFound, "not_found", "not found: ID 500 not found"},
+		{"InvalidID", http.MethodGet, "/todo/abc", "",
+			http.StatusBadRequest, "invalid_data", "invalid data: Invalid ID"},
+		{"InvalidJSON", http.MethodPost, "/todo", "{",
+			http.StatusBadRequest, "invalid_data", "Invalid JSON"},
+		{"MissingTask", http.MethodPut, "/todo/1", "{}",
+			http.StatusBadRequest, "invalid_data", "Missing field 'task'"},
+		{"Method", http.MethodPut, "/todo", "",
+			http.StatusMethodNotAllowed, "method_not_allowed", "Method not supported"},
+		{"InvalidList", http.MethodGet, "/lists/a%20b/todo", "",
+			http.StatusBadRequest, "invalid_data", "invalid list name"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req, err := http.NewRequest(tc.method, url+tc.path, strings.NewReader(tc.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("X-Request-ID", "test-"+tc.name)
+			r, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer r.Body.Close()
+
+			if r.StatusCode != tc.expCode {
+				t.Fatalf("Expected %q, got %q.",
+					http.StatusText(tc.expCode), http.StatusText(r.StatusCode))
+			}
+			if ct := r.Header.Get("Content-Type"); ct != "application/json" {
+				t.Errorf("Expected JSON, got %q", ct)
+			}
+
+			var resp errorResponse
+			if err := json.NewDecoder(r.Body).Decode(&resp); err != nil {
+				t.Fatal(err)
+			}
+			e := resp.Error
+			if e.Code != tc.expErrCode || e.Message != http.StatusText(tc.expCode) {
+				t.Errorf("Unexpected error %+v", e)
+			}
+			if !strings.Contains(e.Details, tc.expDetails) {
+				t.Errorf("Expected details %q, got %q", tc.expDetails, e.Details)
+			}
+			if e.RequestID != "test-"+tc.name || r.Header.Get("X-Request-ID") != e.RequestID {
+				t.Errorf("Expected request ID %q, got %q and header %q",
+					"test-"+tc.name, e.RequestID, r.Header.Get("X-Request-ID"))
+			}
+		})
+	}
+
+	t.Run("NewRequestID", func(t *testing.T) {
+		r, err := http.Get(url + "/todo/500")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer r.Body.Close()
+
+		var resp errorResponse
+		if err := json.NewDecoder(r.Body).Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+		if len(resp.Error.RequestID) != 16 || r.Header.Get("X-Request-ID") != resp.Error.RequestID {
+			t.Errorf("Expected a new request ID, got %q", resp.Error.RequestID)
+		}
+	})
+}
+
+func TestAuth(t *testing.T) {
+	tokenFile := filepath.Join(t.TempDir(), "tokens")
+	content := "# tokens\n\nsecret-a alice\nsecret-b bob\nsecret-r bob read-only\n"
+	if err := os.WriteFile(tokenFile, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	tokens, err := loadTokens(tokenFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	handlers := map[string]http.Handler{}
+	for _, name := range tokens.users() {
+		store, err := todo.OpenStorage("memory:")
+		if err != nil {
+			t.Fatal(err)
+		}
+		handlers[name] = newMux(loadStore(t, store))
+	}
+	ts := httptest.NewServer(newAuthMux(tokens, handlers))
+	defer ts.Close()
+
+	request := func(method, path, token, body string) *http.Response {
+		t.Helper()
+		req, err := http.NewRequest(method, ts.URL+path, strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		r, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r.Body.Close()
+		return r
+	}
+
+	testCases := []struct {
+		name    string
+		method  string
+		path    string
+		token   string
+		expCode int
+	}{
+		{"Root", http.MethodGet, "/", "", http.StatusOK},
+		{"NoToken", http.MethodGet, "/todo", "", http.StatusUnauthorized},
+		{"InvalidToken", http.MethodGet, "/todo", "secret-x", http.StatusUnauthorized},
+		{"Add", http.MethodPost, "/todo", "secret-a", http.StatusCreated},
+		{"ReadOnlyGet", http.MethodGet, "/lists", "secret-r", http.StatusOK},
+		{"ReadOnlyAdd", http.MethodPost, "/todo", "secret-r", http.StatusForbidden},
+		{"ReadOnlyDelete", http.MethodDelete, "/todo/1", "secret-r", http.StatusForbidden},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := request(tc.method, tc.path, tc.token, `{"task":"Task of alice."}`)
+			if r.StatusCode != tc.expCode {
+				t.Errorf("Expected %q, got %q.",
+					http.StatusText(tc.expCode), http.StatusText(r.StatusCode))
+			}
+			if tc.expCode == http.StatusUnauthorized &&
+				!strings.HasPrefix(r.Header.Get("WWW-Authenticate"), "Bearer") {
+				t.Errorf("Expected a Bearer challenge, got %q", r.Header.Get("WWW-Authenticate"))
+			}
+		})
+	}
+
+	t.Run("PerUser", func(t *testing.T) {
+		// alice's item isn't in bob's list
+		if r := request(http.MethodGet, "/todo/1", "secret-a", ""); r.StatusCode != http.StatusOK {
+			t.Errorf("Expected alice's item, got %q", http.StatusText(r.StatusCode))
+		}
+		if r := request(http.MethodGet, "/todo/1", "secret-b", ""); r.StatusCode != http.StatusNotFound {
+			t.Errorf("Expected no item for bob, got %q", http.StatusText(r.StatusCode))
+		}
+	})
+}
+
+func TestLoadTokens(t *testing.T) {
+	testCases := []struct {
+		name    string
+		content string
+		expErr  string
+	}{
+		{"Valid", "t1 alice\nt2 alice read-only\n", ""},
+		{"MissingUser", "t1\n", "expected TOKEN USER"},
+		{"InvalidUser", "t1 ../alice\n", "invalid user name"},
+		{"InvalidOption", "t1 alice admin\n", "expected TOKEN USER"},
+		{"Duplicate", "t1 alice\nt1 bob\n", "duplicate token"},
+		{"Empty", "# no tokens\n", "no tokens"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tokenFile := filepath.Join(t.TempDir(), "tokens")
+			if err := os.WriteFile(tokenFile, []byte(tc.content), 0600); err != nil {
+				t.Fatal(err)
+			}
+			tokens, err := loadTokens(tokenFile)
+			if tc.expErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.expErr) {
+					t.Errorf("Expected error %q, got %v", tc.expErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if u := tokens[tokenHash("t2")]; u.name != "alice" || !u.readOnly {
+				t.Errorf("Unexpected user %+v", u)
+			}
+			if users := tokens.users(); len(users) != 1 || users[0] != "alice" {
+				t.Errorf("Expected users [alice], got %v", users)
+			}
+		})
+	}
+}
+
+func TestUserSpec(t *testing.T) {
+	testCases := []struct {
+		spec string
+		exp  string
+	}{
+		{"todoServer.json", "todoServer.alice.json"},
+		{"data/todo", "data/todo.alice"},
+		{"json:todo.json", "json:todo.alice.json"},
+		{"sqlite:todo.db", "sqlite:todo.alice.db"},
+		{"memory:", "memory:"},
+	}
+	for _, tc := range testCases {
+		if got := userSpec(tc.spec, "alice"); got != tc.exp {
+			t.Errorf("Expected %q for %q, got %q", tc.exp, tc.spec, got)
+		}
+	}
+}
+
+func TestCheckPassphrase(t *testing.T) {
+	testCases := []struct {
+		passphrase string
+		specs      []string
+		expErr     bool
+	}{
+		{"", []string{"sqlite:todo.db"}, false},
+		{"secret", []string{"todo.json"}, false},
+		{"secret", []string{"json:todo.json", "todo.archive.json"}, false},
+		{"secret", []string{"sqlite:todo.db"}, true},
+		{"secret", []string{"SQLite3:todo.db"}, true},
+		{"secret", []string{"memory:"}, true},
+		{"secret", []string{"todo.json", "sqlite:archive.db"}, true},
+	}
+	for _, tc := range testCases {
+		err := checkPassphrase(tc.passphrase, tc.specs...)
+		if tc.expErr {
+			if err == nil || err.Error() != "encryption needs the json storage" {
+				t.Errorf("%v: expected the encryption error, got %v", tc.specs, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%v: unexpected error %v", tc.specs, err)
+		}
+	}
+}
+
 func TestMain(m *testing.M) {
 	log.SetOutput(ioutil.Discard) // discard log info
 	os.Exit(m.Run())
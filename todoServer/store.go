@@ -0,0 +1,138 @@
+package main
+
+import (
+	"sync"
+	"todo"
+)
+
+// listStore keeps the list of a storage in memory, so requests don't
+// read the whole storage. Requests reading the list run concurrently,
+// while changes are made one at a time on a copy of the list, which
+// replaces it once saved. The list is loaded again when another
+// process, such as the todo CLI, changes the storage.
+//
+// Saving only writes the changed items with SQLite storage. A JSON
+// file is still written and synced whole on every change, so lists
+// changed often are better kept in SQLite
+type listStore struct {
+	mu      sync.RWMutex
+	storage todo.Storage
+	list    todo.List
+	// version of the storage the list was loaded or saved as,
+	// always empty for storages without versions
+	version string
+	// stale is set when the version is unknown after saving,
+	// so the list is loaded again
+	stale bool
+}
+
+// newListStore loads the list from storage
+func newListStore(storage todo.Storage) (*listStore, error) {
+	s := &listStore{storage: storage}
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// read calls fn with the list, which fn must not change
+func (s *listStore) read(fn func(list *todo.List)) error {
+	s.mu.RLock()
+	changed, err := s.changed()
+	if err != nil {
+		s.mu.RUnlock()
+		return err
+	}
+
+	if changed {
+		s.mu.RUnlock()
+		s.mu.Lock()
+		// another request may have loaded it meanwhile
+		changed, err = s.changed()
+		if err == nil && changed {
+			err = s.load()
+		}
+		s.mu.Unlock()
+		if err != nil {
+			return err
+		}
+		s.mu.RLock()
+	}
+	defer s.mu.RUnlock()
+
+	fn(&s.list)
+	return nil
+}
+
+// update calls fn with a copy of the list to change, holding the
+// storage lock against other processes. fn calls save to store its
+// changes, otherwise they're dropped
+func (s *listStore) update(fn func(list *todo.List, save func() error)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	unlock, err := s.storage.Lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	changed, err := s.changed()
+	if err != nil {
+		return err
+	}
+	if changed {
+		if err := s.load(); err != nil {
+			return err
+		}
+	}
+
+	list := s.list.Clone()
+	fn(&list, func() error {
+		if err := s.storage.Save(&list); err != nil {
+			return err
+		}
+		s.list = list
+		// the list is saved even when the version can't be read,
+		// failing would have the client apply the change again
+		version, err := s.currentVersion()
+		s.version, s.stale = version, err != nil
+		return nil
+	})
+	return nil
+}
+
+// load reads the list from the storage, with the write lock held
+func (s *listStore) load() error {
+	version, err := s.currentVersion()
+	if err != nil {
+		return err
+	}
+	list := todo.List{}
+	if err := s.storage.Get(&list); err != nil {
+		return err
+	}
+	s.list, s.version, s.stale = list, version, false
+	return nil
+}
+
+// changed reports whether the storage changed since the list was
+// loaded or saved
+func (s *listStore) changed() (bool, error) {
+	if s.stale {
+		return true, nil
+	}
+	version, err := s.currentVersion()
+	if err != nil {
+		return false, err
+	}
+	return version != s.version, nil
+}
+
+func (s *listStore) currentVersion() (string, error) {
+	v, ok := s.storage.(todo.Versioner)
+	if !ok {
+		return "", nil
+	}
+	return v.Version()
+}
@@ -0,0 +1,320 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"todo"
+)
+
+// serve sends a request to h, returning the response
+func serve(h http.Handler, method, path, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	return w
+}
+
+// tasks returns the tasks of the default list served by h
+func tasks(t *testing.T, h http.Handler) []string {
+	t.Helper()
+
+	w := serve(h, http.MethodGet, "/todo", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected %q, got %q.", http.StatusText(http.StatusOK), http.StatusText(w.Code))
+	}
+	var resp todoResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	var tasks []string
+	for _, v := range resp.Results.Items {
+		tasks = append(tasks, v.Task)
+	}
+	return tasks
+}
+
+func TestListStore_ExternalChanges(t *testing.T) {
+	testCases := []struct {
+		name string
+		spec string
+	}{
+		{name: "JSON", spec: "%s/todo.json"},
+		{name: "SQLite", spec: "sqlite:%s/todo.db"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			spec := fmt.Sprintf(tc.spec, t.TempDir())
+			storage, err := todo.OpenStorage(spec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer storage.Close()
+			h := newMux(loadStore(t, storage))
+
+			// another process, such as the todo CLI
+			other, err := todo.OpenStorage(spec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer other.Close()
+			edit := func(task string) {
+				t.Helper()
+				err := todo.Update(other, func(list *todo.List) error {
+					list.Add(task)
+					return nil
+				})
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if w := serve(h, http.MethodPost, "/todo", `{"task":"Task 1"}`); w.Code != http.StatusCreated {
+				t.Fatalf("Failed to add item: Status: %d", w.Code)
+			}
+
+			// seen by the reads
+			edit("Task 2")
+			if got := tasks(t, h); strings.Join(got, ",") != "Task 1,Task 2" {
+				t.Errorf("Expected the external change, got %q", got)
+			}
+
+			// and kept by the changes
+			edit("Task 3")
+			if w := serve(h, http.MethodPost, "/todo", `{"task":"Task 4"}`); w.Code != http.StatusCreated {
+				t.Fatalf("Failed to add item: Status: %d", w.Code)
+			}
+			if got := tasks(t, h); strings.Join(got, ",") != "Task 1,Task 2,Task 3,Task 4" {
+				t.Errorf("Expected all the changes, got %q", got)
+			}
+			saved := todo.List{}
+			if err := other.Get(&saved); err != nil {
+				t.Fatal(err)
+			}
+			if len(saved.Items) != 4 {
+				t.Errorf("Expected 4 saved items, got:\n%s", saved.String())
+			}
+		})
+	}
+}
+
+func TestListStore_FailedChanges(t *testing.T) {
+	storage, err := todo.OpenStorage("memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := newMux(loadStore(t, storage))
+
+	serve(h, http.MethodPost, "/todo", `{"task":"Task 1"}`)
+
+	// the item is added to the list before its
+	// parent is found missing
+	if w := serve(h, http.MethodPost, "/todo", `{"task":"Task 2","parent":9}`); w.Code != http.StatusBadRequest {
+		t.Fatalf("Expected %q, got %q.", http.StatusText(http.StatusBadRequest), http.StatusText(w.Code))
+	}
+	if got := tasks(t, h); len(got) != 1 {
+		t.Errorf("Expected the failed change dropped, got %q", got)
+	}
+}
+
+// flakyVersioner fails to give the version of the storage once
+// it's saved, until fixed is set
+type flakyVersioner struct {
+	todo.Storage
+	saved, fixed bool
+}
+
+func (s *flakyVersioner) Save(list *todo.List) error {
+	s.saved = true
+	return s.Storage.Save(list)
+}
+
+func (s *flakyVersioner) Version() (string, error) {
+	if s.saved && !s.fixed {
+		return "", errors.New("version unavailable")
+	}
+	return "1", nil
+}
+
+func TestListStore_VersionFails(t *testing.T) {
+	memory, err := todo.OpenStorage("memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	storage := &flakyVersioner{Storage: memory}
+	h := newMux(loadStore(t, storage))
+
+	// the change is saved, so it succeeds
+	if w := serve(h, http.MethodPost, "/todo", `{"task":"Task 1"}`); w.Code != http.StatusCreated {
+		t.Fatalf("Expected %q, got %q.", http.StatusText(http.StatusCreated), http.StatusText(w.Code))
+	}
+
+	storage.fixed = true
+	if got := tasks(t, h); strings.Join(got, ",") != "Task 1" {
+		t.Errorf("Expected the saved change, got %q", got)
+	}
+}
+
+func TestListStore_Concurrent(t *testing.T) {
+	storage, err := todo.OpenStorage(filepath.Join(t.TempDir(), "todo.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := newMux(loadStore(t, storage))
+
+	const clients = 20
+	var wg sync.WaitGroup
+	for i := 0; i < clients; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			serve(h, http.MethodPost, "/todo", fmt.Sprintf(`{"task":"Task %d"}`, i))
+			serve(h, http.MethodGet, "/todo", "")
+			serve(h, http.MethodGet, "/lists", "")
+		}(i)
+	}
+	wg.Wait()
+
+	if got := tasks(t, h); len(got) != clients {
+		t.Errorf("Expected %d items, got %d", clients, len(got))
+	}
+}
+
+// slowWriter is a client reading its response slowly: writing
+// the response blocks until release is closed
+type slowWriter struct {
+	*httptest.ResponseRecorder
+	writing chan struct{}
+	release chan struct{}
+}
+
+func (w *slowWriter) Write(p []byte) (int, error) {
+	close(w.writing)
+	<-w.release
+	return w.ResponseRecorder.Write(p)
+}
+
+func TestListStore_SlowClient(t *testing.T) {
+	storage, err := todo.OpenStorage("memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := newMux(loadStore(t, storage))
+	serve(h, http.MethodPost, "/todo", `{"task":"Task 1"}`)
+
+	w := &slowWriter{httptest.NewRecorder(), make(chan struct{}), make(chan struct{})}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/todo", nil))
+	}()
+	<-w.writing
+
+	// the list isn't locked while the response is written
+	added := make(chan int)
+	go func() {
+		added <- serve(h, http.MethodPost, "/todo", `{"task":"Task 2"}`).Code
+	}()
+	select {
+	case code := <-added:
+		if code != http.StatusCreated {
+			t.Errorf("Expected %q, got %q.", http.StatusText(http.StatusCreated), http.StatusText(code))
+		}
+	case <-time.After(5 * time.Second):
+		t.Error("Expected the item added while the slow client reads")
+	}
+	close(w.release)
+	<-done
+}
+
+func TestListStore_Head(t *testing.T) {
+	storage, err := todo.OpenStorage("memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := newMux(loadStore(t, storage))
+	serve(h, http.MethodPost, "/todo", `{"task":"Task 1"}`)
+
+	testCases := []struct {
+		path      string
+		expStatus int
+	}{
+		{"/todo", http.StatusOK},
+		{"/todo/1", http.StatusOK},
+		{"/todo/2", http.StatusNotFound},
+		{"/lists", http.StatusOK},
+		{"/lists/work/todo", http.StatusOK},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.path, func(t *testing.T) {
+			w := serve(h, http.MethodHead, tc.path, "")
+			if w.Code != tc.expStatus {
+				t.Errorf("Expected %q, got %q.", http.StatusText(tc.expStatus), http.StatusText(w.Code))
+			}
+			if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+				t.Errorf("Expected JSON content type, got %q.", ct)
+			}
+		})
+	}
+}
+
+// BenchmarkServer measures the requests served to parallel clients
+// on a list of 1000 items in a JSON file, with a share of them
+// completing items and the rest getting pages of items
+func BenchmarkServer(b *testing.B) {
+	for _, writes := range []int{0, 10} {
+		b.Run(fmt.Sprintf("Writes%d%%", writes), func(b *testing.B) {
+			storage, err := todo.OpenStorage(filepath.Join(b.TempDir(), "todo.json"))
+			if err != nil {
+				b.Fatal(err)
+			}
+			ls := &todo.List{}
+			for i := 1; i <= 1000; i++ {
+				ls.Add(fmt.Sprintf("Task number %d.", i))
+			}
+			if err := storage.Save(ls); err != nil {
+				b.Fatal(err)
+			}
+			store, err := newListStore(storage)
+			if err != nil {
+				b.Fatal(err)
+			}
+			h := newMux(store)
+
+			var (
+				mu sync.Mutex
+				n  int
+			)
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					mu.Lock()
+					n++
+					i := n
+					mu.Unlock()
+
+					var w *httptest.ResponseRecorder
+					if writes > 0 && i%(100/writes) == 0 {
+						w = serve(h, http.MethodPatch, fmt.Sprintf("/todo/%d?complete", i%1000+1), "")
+					} else {
+						w = serve(h, http.MethodGet, fmt.Sprintf("/todo?limit=20&offset=%d", i%1000), "")
+					}
+					if w.Code >= http.StatusBadRequest {
+						b.Errorf("Request failed: Status: %d", w.Code)
+					}
+				}
+			})
+		})
+	}
+}